@@ -16,7 +16,8 @@ import (
 )
 
 var (
-	guestKubeConfig *string
+	guestKubeConfig        *string
+	hostedControlPlaneName *string
 )
 
 func main() {
@@ -44,6 +45,7 @@ func NewOperatorCommand() *cobra.Command {
 	ctrlCmd.Use = "start"
 	ctrlCmd.Short = "Start the Cluster Storage Operator"
 	guestKubeConfig = ctrlCmd.Flags().String("guest-kubeconfig", "", "Path to guest kubeconfig file. This flag enables hypershift integration")
+	hostedControlPlaneName = ctrlCmd.Flags().String("hosted-control-plane-name", "", "Name of the HostedControlPlane in the control plane namespace. If empty, the only HostedControlPlane in the namespace is used")
 
 	cmd.AddCommand(ctrlCmd)
 
@@ -51,5 +53,5 @@ func NewOperatorCommand() *cobra.Command {
 }
 
 func runOperatorWithGuestKubeconfig(ctx context.Context, controllerConfig *controllercmd.ControllerContext) error {
-	return operator.RunOperator(ctx, controllerConfig, guestKubeConfig)
+	return operator.RunOperator(ctx, controllerConfig, guestKubeConfig, hostedControlPlaneName)
 }
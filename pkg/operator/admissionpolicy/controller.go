@@ -0,0 +1,220 @@
+package admissionpolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/features"
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	admissionlisterv1 "k8s.io/client-go/listers/admissionregistration/v1"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	controllerName = "StorageAdmissionPolicyController"
+	// readyConditionType reports that the API server type checked all enabled policies.
+	readyConditionType = controllerName + "PolicyReady"
+
+	// configMapName is an optional ConfigMap in the CSO namespace with policyConfig.
+	configMapName = "storage-admission-policy"
+)
+
+// admissionPolicy is a ValidatingAdmissionPolicy with its binding, enabled by a feature gate.
+type admissionPolicy struct {
+	policyAsset  string
+	bindingAsset string
+	featureGate  configv1.FeatureGateName
+}
+
+var policies = []admissionPolicy{
+	{
+		// Validates storage.openshift.io/fsgroup-change-policy and
+		// storage.openshift.io/selinux-change-policy namespace labels.
+		policyAsset:  "admissionpolicy/storage_policy_validation.yaml",
+		bindingAsset: "admissionpolicy/storage_policy_validation_binding.yaml",
+		featureGate:  features.FeatureGateStoragePerformantSecurityPolicy,
+	},
+}
+
+// policyConfig is the content of storage-admission-policy ConfigMap.
+type policyConfig struct {
+	// FailurePolicy of all storage policies, Fail by default.
+	FailurePolicy admissionv1.FailurePolicyType `yaml:"failurePolicy,omitempty"`
+}
+
+// Controller applies the storage ValidatingAdmissionPolicies and their bindings
+// when their feature gate is enabled and removes them otherwise.
+type Controller struct {
+	operatorClient  v1helpers.OperatorClient
+	kubeClient      kubernetes.Interface
+	configMapLister listerv1.ConfigMapLister
+	policyLister    admissionlisterv1.ValidatingAdmissionPolicyLister
+	featureGates    featuregates.FeatureGate
+	policies        []admissionPolicy
+	eventRecorder   events.Recorder
+	resourceCache   resourceapply.ResourceCache
+}
+
+func NewController(
+	clients *csoclients.Clients,
+	featureGates featuregates.FeatureGate,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+	policyInformer := clients.KubeInformers.InformersFor("").Admissionregistration().V1().ValidatingAdmissionPolicies()
+	c := &Controller{
+		operatorClient:  clients.OperatorClient,
+		kubeClient:      clients.KubeClient,
+		configMapLister: clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+		policyLister:    policyInformer.Lister(),
+		featureGates:    featureGates,
+		policies:        policies,
+		eventRecorder:   eventRecorder.WithComponentSuffix("storage-admission-policy-controller"),
+		resourceCache:   resourceapply.NewResourceCache(),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			c.operatorClient.Informer(),
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+			policyInformer.Informer(),
+			clients.KubeInformers.InformersFor("").Admissionregistration().V1().ValidatingAdmissionPolicyBindings().Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(controllerName, c.eventRecorder)
+}
+
+func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("StorageAdmissionPolicyController sync started")
+	defer klog.V(4).Infof("StorageAdmissionPolicyController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	cfg, err := c.parseConfigMap()
+	if err != nil {
+		return err
+	}
+
+	var notReady []string
+	for _, p := range c.policies {
+		policy, binding, err := readPolicy(p)
+		if err != nil {
+			return err
+		}
+		if !c.featureGates.Enabled(p.featureGate) {
+			if err := c.deletePolicy(ctx, policy, binding); err != nil {
+				return err
+			}
+			continue
+		}
+
+		policy.Spec.FailurePolicy = &cfg.FailurePolicy
+		actual, _, err := resourceapply.ApplyValidatingAdmissionPolicyV1(ctx, c.kubeClient.AdmissionregistrationV1(), c.eventRecorder, policy, c.resourceCache)
+		if err != nil {
+			return err
+		}
+		if _, _, err := resourceapply.ApplyValidatingAdmissionPolicyBindingV1(ctx, c.kubeClient.AdmissionregistrationV1(), c.eventRecorder, binding, c.resourceCache); err != nil {
+			return err
+		}
+		// The policy status is filled asynchronously, prefer the informer copy when it's up to date
+		if cached, err := c.policyLister.Get(actual.Name); err == nil && cached.Generation >= actual.Generation {
+			actual = cached
+		}
+		if msg := policyNotReadyMessage(actual); msg != "" {
+			notReady = append(notReady, msg)
+		}
+	}
+
+	readyCnd := operatorapi.OperatorCondition{
+		Type:   readyConditionType,
+		Status: operatorapi.ConditionTrue,
+		Reason: "AsExpected",
+	}
+	if len(notReady) > 0 {
+		readyCnd.Status = operatorapi.ConditionFalse
+		readyCnd.Reason = "TypeCheckingIncomplete"
+		readyCnd.Message = strings.Join(notReady, "; ")
+	}
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(readyCnd))
+	return err
+}
+
+func (c *Controller) deletePolicy(ctx context.Context, policy *admissionv1.ValidatingAdmissionPolicy, binding *admissionv1.ValidatingAdmissionPolicyBinding) error {
+	if _, err := c.policyLister.Get(policy.Name); apierrors.IsNotFound(err) {
+		return nil
+	}
+	if _, _, err := resourceapply.DeleteValidatingAdmissionPolicyBindingV1(ctx, c.kubeClient.AdmissionregistrationV1(), c.eventRecorder, binding); err != nil {
+		return err
+	}
+	_, _, err := resourceapply.DeleteValidatingAdmissionPolicyV1(ctx, c.kubeClient.AdmissionregistrationV1(), c.eventRecorder, policy)
+	return err
+}
+
+// policyNotReadyMessage returns why the API server has not accepted the policy yet,
+// or an empty string when the policy is ready.
+func policyNotReadyMessage(policy *admissionv1.ValidatingAdmissionPolicy) string {
+	if policy.Status.ObservedGeneration < policy.Generation || policy.Status.TypeChecking == nil {
+		return fmt.Sprintf("ValidatingAdmissionPolicy %s was not type checked yet", policy.Name)
+	}
+	if warnings := policy.Status.TypeChecking.ExpressionWarnings; len(warnings) > 0 {
+		var msgs []string
+		for _, w := range warnings {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", w.FieldRef, w.Warning))
+		}
+		return fmt.Sprintf("ValidatingAdmissionPolicy %s has type checking warnings: %s", policy.Name, strings.Join(msgs, ", "))
+	}
+	return ""
+}
+
+func readPolicy(p admissionPolicy) (*admissionv1.ValidatingAdmissionPolicy, *admissionv1.ValidatingAdmissionPolicyBinding, error) {
+	policyBytes, err := assets.ReadFile(p.policyAsset)
+	if err != nil {
+		return nil, nil, err
+	}
+	bindingBytes, err := assets.ReadFile(p.bindingAsset)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resourceread.ReadValidatingAdmissionPolicyV1OrDie(policyBytes), resourceread.ReadValidatingAdmissionPolicyBindingV1OrDie(bindingBytes), nil
+}
+
+func (c *Controller) parseConfigMap() (*policyConfig, error) {
+	cfg := &policyConfig{FailurePolicy: admissionv1.Fail}
+	if _, err := csoutils.ParseOperatorConfigMap(c.configMapLister, configMapName, cfg); err != nil {
+		return nil, err
+	}
+	switch cfg.FailurePolicy {
+	case admissionv1.Fail, admissionv1.Ignore:
+	case "":
+		cfg.FailurePolicy = admissionv1.Fail
+	default:
+		return nil, fmt.Errorf("invalid config in ConfigMap %s: unknown failurePolicy %q, expected %s or %s",
+			configMapName, cfg.FailurePolicy, admissionv1.Fail, admissionv1.Ignore)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,169 @@
+package admissionpolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/features"
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const policyName = "openshift-storage-policy-validation"
+
+func getCM(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+func existingPolicy(typeChecked bool) *admissionv1.ValidatingAdmissionPolicy {
+	policy := &admissionv1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: policyName, Generation: 1},
+	}
+	if typeChecked {
+		policy.Status.ObservedGeneration = 1
+		policy.Status.TypeChecking = &admissionv1.TypeChecking{}
+	}
+	return policy
+}
+
+func TestController(t *testing.T) {
+	enabled := featuregates.NewFeatureGate([]configv1.FeatureGateName{features.FeatureGateStoragePerformantSecurityPolicy}, nil)
+	disabled := featuregates.NewFeatureGate(nil, []configv1.FeatureGateName{features.FeatureGateStoragePerformantSecurityPolicy})
+
+	tests := []struct {
+		name                  string
+		featureGates          featuregates.FeatureGate
+		objects               []runtime.Object
+		expectErr             string
+		expectPolicy          bool
+		expectedFailurePolicy admissionv1.FailurePolicyType
+		expectedReady         opv1.ConditionStatus
+	}{
+		{
+			name:                  "enabled policy is applied",
+			featureGates:          enabled,
+			expectPolicy:          true,
+			expectedFailurePolicy: admissionv1.Fail,
+			// The fake client does not type check
+			expectedReady: opv1.ConditionFalse,
+		},
+		{
+			name:                  "type checked policy is ready",
+			featureGates:          enabled,
+			objects:               []runtime.Object{existingPolicy(true)},
+			expectPolicy:          true,
+			expectedFailurePolicy: admissionv1.Fail,
+			expectedReady:         opv1.ConditionTrue,
+		},
+		{
+			name:                  "custom failure policy",
+			featureGates:          enabled,
+			objects:               []runtime.Object{getCM("failurePolicy: Ignore\n")},
+			expectPolicy:          true,
+			expectedFailurePolicy: admissionv1.Ignore,
+			expectedReady:         opv1.ConditionFalse,
+		},
+		{
+			name:          "disabled policy is removed",
+			featureGates:  disabled,
+			objects:       []runtime.Object{existingPolicy(true)},
+			expectedReady: opv1.ConditionTrue,
+		},
+		{
+			name:         "invalid failure policy",
+			featureGates: enabled,
+			objects:      []runtime.Object{getCM("failurePolicy: Sometimes\n")},
+			expectErr:    `unknown failurePolicy "Sometimes"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     tt.objects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+			})
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewController(clients, tt.featureGates, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				clients.KubeInformers.InformersFor("").Admissionregistration().V1().ValidatingAdmissionPolicies().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctrl.Sync(context.TODO(), nil)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expected error %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			policy, err := clients.KubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicies().Get(context.TODO(), policyName, metav1.GetOptions{})
+			_, bindingErr := clients.KubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicyBindings().Get(context.TODO(), policyName+"-binding", metav1.GetOptions{})
+			if !tt.expectPolicy {
+				if !apierrors.IsNotFound(err) || !apierrors.IsNotFound(bindingErr) {
+					t.Errorf("expected policy and binding to be removed, got: %v, %v", err, bindingErr)
+				}
+			} else {
+				if err != nil || bindingErr != nil {
+					t.Fatalf("failed to get policy and binding: %v, %v", err, bindingErr)
+				}
+				if policy.Spec.FailurePolicy == nil || *policy.Spec.FailurePolicy != tt.expectedFailurePolicy {
+					t.Errorf("expected failurePolicy %s, got %v", tt.expectedFailurePolicy, policy.Spec.FailurePolicy)
+				}
+			}
+
+			_, status, _, err := clients.OperatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("failed to get Storage: %v", err)
+			}
+			cnd := v1helpers.FindOperatorCondition(status.Conditions, readyConditionType)
+			if cnd == nil || cnd.Status != tt.expectedReady {
+				t.Errorf("expected condition %s=%s, got %+v", readyConditionType, tt.expectedReady, cnd)
+			}
+		})
+	}
+}
+
+func TestPolicyNotReadyMessage(t *testing.T) {
+	policy := existingPolicy(true)
+	policy.Status.TypeChecking.ExpressionWarnings = []admissionv1.ExpressionWarning{
+		{FieldRef: "spec.validations[0].expression", Warning: "undefined field 'foo'"},
+	}
+	msg := policyNotReadyMessage(policy)
+	if !strings.Contains(msg, "type checking warnings: spec.validations[0].expression: undefined field 'foo'") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+	if msg := policyNotReadyMessage(existingPolicy(true)); msg != "" {
+		t.Errorf("expected ready policy, got: %s", msg)
+	}
+}
@@ -0,0 +1,215 @@
+package csidriveroperator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorapi "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CloudConfigSyncLabel marks cloud config ConfigMaps created by CloudConfigSyncController.
+	// Only ConfigMaps with the label are updated or removed, except the adopted
+	// cloud-provider-config of previous releases.
+	CloudConfigSyncLabel = "storage.openshift.io/synced-cloud-config"
+
+	cloudConfigSyncControllerName = "CloudConfigSyncController"
+)
+
+// CloudConfigSyncController copies the cluster cloud config ConfigMap, named by
+// Infrastructure.Spec.CloudConfig.Name in openshift-config, to the namespace of CSI driver
+// operators, so the operators can get the cloud CA certificate as a ConfigMap volume.
+// There is one controller per target ConfigMap, shared by all CSI drivers that mount it.
+// It syncs only on the platforms of these CSI drivers. A target ConfigMap that was not
+// created by CSO is never updated nor removed, except cloud-provider-config created without
+// the label by the Manila CA certificate syncer of previous releases, which is adopted.
+// The target is removed when the Infrastructure has no cloud config.
+// It runs only in standalone clusters, HyperShift operators mount the cloud config provided by HyperShift.
+// It produces <platform>CloudConfigSyncControllerDegraded condition when the sync fails.
+type CloudConfigSyncController struct {
+	operatorClient  v1helpers.OperatorClient
+	infraLister     configv1listers.InfrastructureLister
+	sourceLister    corelisters.ConfigMapNamespaceLister
+	targetClient    corev1client.ConfigMapsGetter
+	targetLister    corelisters.ConfigMapNamespaceLister
+	targetNamespace string
+	targetName      string
+	platforms       sets.Set[configv1.PlatformType]
+	eventRecorder   events.Recorder
+}
+
+// NewCloudConfigSyncControllers returns one CloudConfigSyncController for each
+// CloudConfigSyncTarget of the CSI driver configs.
+func NewCloudConfigSyncControllers(
+	clients *csoclients.Clients,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) []factory.Controller {
+
+	var targets []string
+	platforms := map[string][]configv1.PlatformType{}
+	for _, cfg := range csiDriverConfigs {
+		if cfg.CloudConfigSyncTarget == "" {
+			continue
+		}
+		if _, found := platforms[cfg.CloudConfigSyncTarget]; !found {
+			targets = append(targets, cfg.CloudConfigSyncTarget)
+		}
+		platforms[cfg.CloudConfigSyncTarget] = append(platforms[cfg.CloudConfigSyncTarget], cfg.Platform)
+	}
+
+	var controllers []factory.Controller
+	for _, target := range targets {
+		controllers = append(controllers, NewCloudConfigSyncController(
+			clients, target, platforms[target], resyncInterval, eventRecorder))
+	}
+	return controllers
+}
+
+func NewCloudConfigSyncController(
+	clients *csoclients.Clients,
+	targetName string,
+	platforms []configv1.PlatformType,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+
+	conditionPrefix := string(platforms[0])
+
+	sourceInformer := clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps()
+	targetInformer := clients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Core().V1().ConfigMaps()
+
+	c := &CloudConfigSyncController{
+		operatorClient:  clients.OperatorClient,
+		infraLister:     clients.ConfigInformers.Config().V1().Infrastructures().Lister(),
+		sourceLister:    sourceInformer.Lister().ConfigMaps(csoclients.CloudConfigNamespace),
+		targetClient:    clients.KubeClient.CoreV1(),
+		targetLister:    targetInformer.Lister().ConfigMaps(csoclients.CSIOperatorNamespace),
+		targetNamespace: csoclients.CSIOperatorNamespace,
+		targetName:      targetName,
+		platforms:       sets.New(platforms...),
+		eventRecorder:   eventRecorder.WithComponentSuffix(conditionPrefix),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			clients.OperatorClient.Informer(),
+			clients.ConfigInformers.Config().V1().Infrastructures().Informer(),
+			sourceInformer.Informer(),
+			targetInformer.Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(conditionPrefix+cloudConfigSyncControllerName, eventRecorder)
+}
+
+func (c *CloudConfigSyncController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("CloudConfigSyncController sync started")
+	defer klog.V(4).Infof("CloudConfigSyncController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	infra, err := c.infraLister.Get(infraConfigName)
+	if err != nil {
+		return fmt.Errorf("failed to get infrastructure resource: %w", err)
+	}
+	if infra.Status.PlatformStatus == nil || !c.platforms.Has(infra.Status.PlatformStatus.Type) {
+		return nil
+	}
+	sourceName := infra.Spec.CloudConfig.Name
+	if sourceName == "" {
+		klog.V(4).Infof("Infrastructure has no cloud config, removing %s/%s", c.targetNamespace, c.targetName)
+		return c.deleteTarget(ctx)
+	}
+
+	source, err := c.sourceLister.Get(sourceName)
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("cloud config ConfigMap %s/%s not found", csoclients.CloudConfigNamespace, sourceName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get cloud config ConfigMap %s/%s: %w", csoclients.CloudConfigNamespace, sourceName, err)
+	}
+
+	if owned, err := c.ownsTarget(true); err != nil || !owned {
+		return err
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.targetName,
+			Namespace: c.targetNamespace,
+			Labels:    map[string]string{CloudConfigSyncLabel: "true"},
+		},
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.targetClient, c.eventRecorder, required)
+	if err != nil {
+		return fmt.Errorf("failed to sync cloud config ConfigMap %s/%s to %s/%s: %w",
+			csoclients.CloudConfigNamespace, sourceName, c.targetNamespace, c.targetName, err)
+	}
+	return nil
+}
+
+// ownsTarget returns false when the target ConfigMap exists and was not created by the controller.
+// With adoptLegacy, the unlabeled target created by the Manila CA certificate syncer of previous
+// releases is owned too, applying it adds the label.
+func (c *CloudConfigSyncController) ownsTarget(adoptLegacy bool) (bool, error) {
+	target, err := c.targetLister.Get(c.targetName)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if target.Labels[CloudConfigSyncLabel] == "true" {
+		return true, nil
+	}
+	if adoptLegacy && c.targetName == csioperatorclient.CloudConfigName {
+		klog.V(2).Infof("Adopting %s/%s created by a previous release", c.targetNamespace, c.targetName)
+		return true, nil
+	}
+	klog.V(4).Infof("Not syncing %s/%s, it was not created by CSO", c.targetNamespace, c.targetName)
+	return false, nil
+}
+
+// deleteTarget removes the target ConfigMap when it was created by the controller.
+func (c *CloudConfigSyncController) deleteTarget(ctx context.Context) error {
+	if _, err := c.targetLister.Get(c.targetName); apierrors.IsNotFound(err) {
+		return nil
+	}
+	if owned, err := c.ownsTarget(false); err != nil || !owned {
+		return err
+	}
+	_, _, err := resourceapply.DeleteConfigMap(ctx, c.targetClient, c.eventRecorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.targetName,
+			Namespace: c.targetNamespace,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove cloud config ConfigMap %s/%s: %w", c.targetNamespace, c.targetName, err)
+	}
+	return nil
+}
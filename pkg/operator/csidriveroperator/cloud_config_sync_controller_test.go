@@ -0,0 +1,239 @@
+package csidriveroperator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const cloudConfigSyncTarget = "cloud-provider-config"
+
+func cloudConfigInfra(cloudConfigName string) *configv1.Infrastructure {
+	return cloudConfigInfraForPlatform(configv1.OpenStackPlatformType, cloudConfigName)
+}
+
+func cloudConfigInfraForPlatform(platform configv1.PlatformType, cloudConfigName string) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: infraConfigName},
+		Spec: configv1.InfrastructureSpec{
+			CloudConfig: configv1.ConfigMapFileReference{Name: cloudConfigName, Key: "config"},
+		},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{Type: platform},
+		},
+	}
+}
+
+func cloudConfigMap(namespace, name, caBundle string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data: map[string]string{
+			"config":        "[Global]\n",
+			"ca-bundle.pem": caBundle,
+		},
+	}
+}
+
+func syncedCloudConfigMap(namespace, name, caBundle string) *corev1.ConfigMap {
+	cm := cloudConfigMap(namespace, name, caBundle)
+	cm.Labels = map[string]string{CloudConfigSyncLabel: "true"}
+	return cm
+}
+
+func TestCloudConfigSyncController(t *testing.T) {
+	tests := []struct {
+		name           string
+		infra          *configv1.Infrastructure
+		coreObjects    []runtime.Object
+		targetName     string
+		expectErr      bool
+		expectedTarget *corev1.ConfigMap
+	}{
+		{
+			name:           "default cloud config name",
+			infra:          cloudConfigInfra("cloud-provider-config"),
+			coreObjects:    []runtime.Object{cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "ca1")},
+			expectedTarget: syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca1"),
+		},
+		{
+			name:  "custom cloud config name",
+			infra: cloudConfigInfra("my-openstack-config"),
+			coreObjects: []runtime.Object{
+				cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "stale"),
+				cloudConfigMap(csoclients.CloudConfigNamespace, "my-openstack-config", "ca2"),
+			},
+			expectedTarget: syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca2"),
+		},
+		{
+			name:  "existing target is updated",
+			infra: cloudConfigInfra("cloud-provider-config"),
+			coreObjects: []runtime.Object{
+				cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "ca-new"),
+				syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-old"),
+			},
+			expectedTarget: syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-new"),
+		},
+		{
+			// Created without the label by the Manila CA certificate syncer before upgrade
+			name:  "unlabeled target of a previous release is adopted",
+			infra: cloudConfigInfra("cloud-provider-config"),
+			coreObjects: []runtime.Object{
+				cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "ca-new"),
+				cloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-old"),
+			},
+			expectedTarget: syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-new"),
+		},
+		{
+			name:  "existing target not created by CSO is not updated",
+			infra: cloudConfigInfra("cloud-provider-config"),
+			coreObjects: []runtime.Object{
+				cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "ca-new"),
+				cloudConfigMap(csoclients.CSIOperatorNamespace, "my-cloud-config", "ca-old"),
+			},
+			targetName:     "my-cloud-config",
+			expectedTarget: cloudConfigMap(csoclients.CSIOperatorNamespace, "my-cloud-config", "ca-old"),
+		},
+		{
+			name:      "missing source",
+			infra:     cloudConfigInfra("my-openstack-config"),
+			expectErr: true,
+		},
+		{
+			name:  "no cloud config",
+			infra: cloudConfigInfra(""),
+		},
+		{
+			name:        "no cloud config removes target",
+			infra:       cloudConfigInfra(""),
+			coreObjects: []runtime.Object{syncedCloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-old")},
+		},
+		{
+			name:           "no cloud config keeps target not created by CSO",
+			infra:          cloudConfigInfra(""),
+			coreObjects:    []runtime.Object{cloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-old")},
+			expectedTarget: cloudConfigMap(csoclients.CSIOperatorNamespace, cloudConfigSyncTarget, "ca-old"),
+		},
+		{
+			name:        "other platform is not synced",
+			infra:       cloudConfigInfraForPlatform(configv1.AWSPlatformType, "cloud-provider-config"),
+			coreObjects: []runtime.Object{cloudConfigMap(csoclients.CloudConfigNamespace, "cloud-provider-config", "ca1")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     test.coreObjects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+				ConfigObjects:   []runtime.Object{test.infra},
+			})
+			targetName := test.targetName
+			if targetName == "" {
+				targetName = cloudConfigSyncTarget
+			}
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewCloudConfigSyncController(clients, targetName, []configv1.PlatformType{configv1.OpenStackPlatformType}, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.ConfigInformers.Config().V1().Infrastructures().Informer().HasSynced,
+				clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				clients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctrl.Sync(context.TODO(), nil)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			targets, err := clients.KubeClient.CoreV1().ConfigMaps(csoclients.CSIOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
+			assert.NoError(t, err)
+			if test.expectedTarget == nil {
+				assert.Empty(t, targets.Items)
+				return
+			}
+			target, err := clients.KubeClient.CoreV1().ConfigMaps(csoclients.CSIOperatorNamespace).Get(context.TODO(), targetName, metav1.GetOptions{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expectedTarget.Data, target.Data)
+				assert.Equal(t, test.expectedTarget.Labels, target.Labels)
+			}
+		})
+	}
+}
+
+func TestNewCloudConfigSyncControllers(t *testing.T) {
+	clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+		OperatorObjects: []runtime.Object{csoclients.GetCR()},
+	})
+	recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+	configs := []csioperatorclient.CSIOperatorConfig{
+		csioperatorclient.GetOpenStackCinderCSIOperatorConfig(false),
+		csioperatorclient.GetOpenStackManilaOperatorConfig(false),
+		csioperatorclient.GetAWSEBSCSIOperatorConfig(false),
+	}
+
+	// Cinder and Manila mount the same ConfigMap, one controller syncs it
+	controllers := NewCloudConfigSyncControllers(clients, configs, time.Hour, recorder)
+	assert.Len(t, controllers, 1)
+}
+
+func TestCloudConfigSyncTarget(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         csioperatorclient.CSIOperatorConfig
+		expectedTarget string
+	}{
+		{
+			name:           "cinder",
+			config:         csioperatorclient.GetOpenStackCinderCSIOperatorConfig(false),
+			expectedTarget: csioperatorclient.CloudConfigName,
+		},
+		{
+			name:           "cinder hypershift",
+			config:         csioperatorclient.GetOpenStackCinderCSIOperatorConfig(true),
+			expectedTarget: "",
+		},
+		{
+			name:           "manila",
+			config:         csioperatorclient.GetOpenStackManilaOperatorConfig(false),
+			expectedTarget: csioperatorclient.CloudConfigName,
+		},
+		{
+			name:           "manila hypershift",
+			config:         csioperatorclient.GetOpenStackManilaOperatorConfig(true),
+			expectedTarget: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedTarget, test.config.CloudConfigSyncTarget)
+			if test.expectedTarget == "" {
+				return
+			}
+			// The target must be the ConfigMap the CSI driver operator mounts
+			deployment, err := assets.ReadFile(test.config.DeploymentAsset)
+			if assert.NoError(t, err) {
+				assert.Contains(t, string(deployment), "name: "+test.expectedTarget+"\n")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -24,14 +25,18 @@ func GetAWSEBSCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   AWSEBSCSIDriverName,
-		ConditionPrefix: "AWSEBS",
-		Platform:        configv1.AWSPlatformType,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+		CSIDriverName:      AWSEBSCSIDriverName,
+		NodeDaemonSetNames: []string{"aws-ebs-csi-driver-node"},
+		ConditionPrefix:    "AWSEBS",
+		Platform:           configv1.AWSPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
 
 	if !isHypershift {
+		csiDriverConfig.CredentialsSecretName = "ebs-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.ShortTermCredentials = awsShortTermCredentials()
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/aws-ebs/standalone/generated/v1_configmap_aws-ebs-csi-driver-operator-config.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/aws-ebs/standalone/generated/v1_serviceaccount_aws-ebs-csi-driver-operator.yaml",
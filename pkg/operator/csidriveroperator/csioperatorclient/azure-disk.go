@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -26,14 +27,18 @@ func GetAzureDiskCSIOperatorConfig(isHyperShift bool) CSIOperatorConfig {
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   AzureDiskDriverName,
-		ConditionPrefix: "AzureDisk",
-		Platform:        configv1.AzurePlatformType,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+		CSIDriverName:      AzureDiskDriverName,
+		NodeDaemonSetNames: []string{"azure-disk-csi-driver-node"},
+		ConditionPrefix:    "AzureDisk",
+		Platform:           configv1.AzurePlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
 
 	if !isHyperShift {
+		csiDriverConfig.CredentialsSecretName = "azure-disk-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.ShortTermCredentials = azureShortTermCredentials(csiDriverConfig.CredentialsSecretName)
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/azure-disk/standalone/generated/v1_configmap_azure-disk-csi-driver-operator-config.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/azure-disk/standalone/generated/v1_serviceaccount_azure-disk-csi-driver-operator.yaml",
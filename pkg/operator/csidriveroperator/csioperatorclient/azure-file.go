@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -37,15 +38,19 @@ func GetAzureFileCSIOperatorConfig(isHyperShift bool) CSIOperatorConfig {
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   AzureFileDriverName,
-		ConditionPrefix: "AzureFile",
-		Platform:        configv1.AzurePlatformType,
-		StatusFilter:    IsNotAzueStackCloud,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+		CSIDriverName:      AzureFileDriverName,
+		NodeDaemonSetNames: []string{"azure-file-csi-driver-node"},
+		ConditionPrefix:    "AzureFile",
+		Platform:           configv1.AzurePlatformType,
+		StatusFilter:       IsNotAzueStackCloud,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
 
 	if !isHyperShift {
+		csiDriverConfig.CredentialsSecretName = "azure-file-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.ShortTermCredentials = azureShortTermCredentials(csiDriverConfig.CredentialsSecretName)
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/azure-file/standalone/generated/v1_configmap_azure-file-csi-driver-operator-config.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/azure-file/standalone/generated/v1_serviceaccount_azure-file-csi-driver-operator.yaml",
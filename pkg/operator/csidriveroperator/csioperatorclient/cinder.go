@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -23,14 +24,18 @@ func GetOpenStackCinderCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   OpenStackCinderDriverName,
-		ConditionPrefix: "OpenStackCinder",
-		Platform:        configv1.OpenStackPlatformType,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+		CSIDriverName:      OpenStackCinderDriverName,
+		NodeDaemonSetNames: []string{"openstack-cinder-csi-driver-node"},
+		ConditionPrefix:    "OpenStackCinder",
+		Platform:           configv1.OpenStackPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
 
 	if !isHypershift {
+		csiDriverConfig.CloudConfigSyncTarget = CloudConfigName
+		csiDriverConfig.CredentialsSecretName = "openstack-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/openstack-cinder/standalone/generated/v1_configmap_openstack-cinder-csi-driver-operator-config.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/openstack-cinder/standalone/generated/v1_serviceaccount_openstack-cinder-csi-driver-operator.yaml",
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -13,29 +14,57 @@ const (
 	envGCPPDDriverImage         = "GCP_PD_DRIVER_IMAGE"
 )
 
-func GetGCPPDCSIOperatorConfig() CSIOperatorConfig {
+func GetGCPPDCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	pairs := []string{
 		"${OPERATOR_IMAGE}", os.Getenv(envGCPPDDriverOperatorImage),
 		"${DRIVER_IMAGE}", os.Getenv(envGCPPDDriverImage),
 		"${OPERATOR_IMAGE_VERSION}", os.Getenv(envOperatorImageVersion),
 	}
 
-	return CSIOperatorConfig{
-		CSIDriverName:                 GCPPDCSIDriverName,
-		ConditionPrefix:               "GCPPD",
-		Platform:                      configv1.GCPPlatformType,
-		StandaloneOperatorConfigAsset: "csidriveroperators/gcp-pd/03_configmap.yaml",
-		StaticAssets: []string{
-			"csidriveroperators/gcp-pd/01_service.yaml",
-			"csidriveroperators/gcp-pd/02_sa.yaml",
-			"csidriveroperators/gcp-pd/03_role.yaml",
-			"csidriveroperators/gcp-pd/04_rolebinding.yaml",
-			"csidriveroperators/gcp-pd/05_clusterrole.yaml",
-			"csidriveroperators/gcp-pd/06_clusterrolebinding.yaml",
-		},
-		CRAsset:         "csidriveroperators/gcp-pd/08_cr.yaml",
-		DeploymentAsset: "csidriveroperators/gcp-pd/07_deployment.yaml",
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+	csiDriverConfig := CSIOperatorConfig{
+		CSIDriverName:      GCPPDCSIDriverName,
+		NodeDaemonSetNames: []string{"gcp-pd-csi-driver-node"},
+		ConditionPrefix:    "GCPPD",
+		Platform:           configv1.GCPPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
+
+	if !isHypershift {
+		csiDriverConfig.CredentialsSecretName = "gcp-pd-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.ShortTermCredentials = gcpShortTermCredentials()
+		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/gcp-pd/standalone/generated/v1_configmap_gcp-pd-csi-driver-operator-config.yaml"
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/gcp-pd/standalone/generated/v1_serviceaccount_gcp-pd-csi-driver-operator.yaml",
+			"csidriveroperators/gcp-pd/standalone/generated/rbac.authorization.k8s.io_v1_role_gcp-pd-csi-driver-operator-role.yaml",
+			"csidriveroperators/gcp-pd/standalone/generated/rbac.authorization.k8s.io_v1_rolebinding_gcp-pd-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/gcp-pd/standalone/generated/rbac.authorization.k8s.io_v1_clusterrole_gcp-pd-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/gcp-pd/standalone/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_gcp-pd-csi-driver-operator-clusterrolebinding.yaml",
+			"csidriveroperators/gcp-pd/standalone/generated/v1_service_gcp-pd-csi-driver-operator-metrics.yaml",
+		}
+		csiDriverConfig.CRAsset = "csidriveroperators/gcp-pd/standalone/generated/operator.openshift.io_v1_clustercsidriver_pd.csi.storage.gke.io.yaml"
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/gcp-pd/standalone/generated/apps_v1_deployment_gcp-pd-csi-driver-operator.yaml"
+	} else {
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/gcp-pd/hypershift/guest/generated/v1_serviceaccount_gcp-pd-csi-driver-operator.yaml",
+			"csidriveroperators/gcp-pd/hypershift/guest/generated/rbac.authorization.k8s.io_v1_role_gcp-pd-csi-driver-operator-role.yaml",
+			"csidriveroperators/gcp-pd/hypershift/guest/generated/rbac.authorization.k8s.io_v1_rolebinding_gcp-pd-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/gcp-pd/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrole_gcp-pd-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/gcp-pd/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_gcp-pd-csi-driver-operator-clusterrolebinding.yaml",
+		}
+		csiDriverConfig.MgmtOperatorConfigAsset = "csidriveroperators/gcp-pd/hypershift/mgmt/generated/v1_configmap_gcp-pd-csi-driver-operator-config.yaml"
+		csiDriverConfig.MgmtStaticAssets = []string{
+			"csidriveroperators/gcp-pd/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_role_gcp-pd-csi-driver-operator-role.yaml",
+			"csidriveroperators/gcp-pd/hypershift/mgmt/generated/v1_serviceaccount_gcp-pd-csi-driver-operator.yaml",
+			"csidriveroperators/gcp-pd/hypershift/mgmt/generated/v1_service_gcp-pd-csi-driver-operator-metrics.yaml",
+			"csidriveroperators/gcp-pd/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_rolebinding_gcp-pd-csi-driver-operator-rolebinding.yaml",
+		}
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/gcp-pd/hypershift/mgmt/generated/apps_v1_deployment_gcp-pd-csi-driver-operator.yaml"
+		csiDriverConfig.CRAsset = "csidriveroperators/gcp-pd/hypershift/guest/generated/operator.openshift.io_v1_clustercsidriver_pd.csi.storage.gke.io.yaml"
+	}
+
+	csiDriverConfig.CSIDriverDeploymentName = getCSIDriverDeploymentName(csiDriverConfig.DeploymentAsset)
+
+	return csiDriverConfig
 }
@@ -7,6 +7,7 @@ import (
 	"k8s.io/klog/v2"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -28,29 +29,57 @@ func isNotExternalTopologyMode(status *configv1.InfrastructureStatus, isInstalle
 	return true
 }
 
-func GetIBMVPCBlockCSIOperatorConfig() CSIOperatorConfig {
+func GetIBMVPCBlockCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	pairs := []string{
 		"${OPERATOR_IMAGE}", os.Getenv(envIBMVPCBlockDriverOperatorImage),
 		"${DRIVER_IMAGE}", os.Getenv(envIBMVPCBlockDriverImage),
 	}
 
-	return CSIOperatorConfig{
-		CSIDriverName:                 IBMVPCBlockCSIDriverName,
-		ConditionPrefix:               "IBMVPCBlock",
-		Platform:                      configv1.IBMCloudPlatformType,
-		StatusFilter:                  isNotExternalTopologyMode,
-		StandaloneOperatorConfigAsset: "csidriveroperators/ibm-vpc-block/03_configmap.yaml",
-		StaticAssets: []string{
-			"csidriveroperators/ibm-vpc-block/01_service.yaml",
-			"csidriveroperators/ibm-vpc-block/03_sa.yaml",
-			"csidriveroperators/ibm-vpc-block/04_role.yaml",
-			"csidriveroperators/ibm-vpc-block/05_rolebinding.yaml",
-			"csidriveroperators/ibm-vpc-block/06_clusterrole.yaml",
-			"csidriveroperators/ibm-vpc-block/07_clusterrolebinding.yaml",
-		},
-		CRAsset:         "csidriveroperators/ibm-vpc-block/09_cr.yaml",
-		DeploymentAsset: "csidriveroperators/ibm-vpc-block/08_deployment.yaml",
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+	csiDriverConfig := CSIOperatorConfig{
+		CSIDriverName:      IBMVPCBlockCSIDriverName,
+		NodeDaemonSetNames: []string{"ibm-vpc-block-csi-node"},
+		ConditionPrefix:    "IBMVPCBlock",
+		Platform:           configv1.IBMCloudPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
+
+	if !isHypershift {
+		// HyperShift guest clusters always report External topology, the filter applies to standalone only.
+		csiDriverConfig.StatusFilter = isNotExternalTopologyMode
+		csiDriverConfig.CredentialsSecretName = "ibm-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/ibm-vpc-block/standalone/generated/v1_configmap_ibm-vpc-block-csi-driver-operator-config.yaml"
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/ibm-vpc-block/standalone/generated/v1_serviceaccount_ibm-vpc-block-csi-driver-operator.yaml",
+			"csidriveroperators/ibm-vpc-block/standalone/generated/rbac.authorization.k8s.io_v1_role_ibm-vpc-block-csi-driver-operator-role.yaml",
+			"csidriveroperators/ibm-vpc-block/standalone/generated/rbac.authorization.k8s.io_v1_rolebinding_ibm-vpc-block-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/ibm-vpc-block/standalone/generated/rbac.authorization.k8s.io_v1_clusterrole_ibm-vpc-block-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/ibm-vpc-block/standalone/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_ibm-vpc-block-csi-driver-operator-clusterrolebinding.yaml",
+			"csidriveroperators/ibm-vpc-block/standalone/generated/v1_service_ibm-vpc-block-csi-driver-operator-metrics.yaml",
+		}
+		csiDriverConfig.CRAsset = "csidriveroperators/ibm-vpc-block/standalone/generated/operator.openshift.io_v1_clustercsidriver_vpc.block.csi.ibm.io.yaml"
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/ibm-vpc-block/standalone/generated/apps_v1_deployment_ibm-vpc-block-csi-driver-operator.yaml"
+	} else {
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/ibm-vpc-block/hypershift/guest/generated/v1_serviceaccount_ibm-vpc-block-csi-driver-operator.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/guest/generated/rbac.authorization.k8s.io_v1_role_ibm-vpc-block-csi-driver-operator-role.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/guest/generated/rbac.authorization.k8s.io_v1_rolebinding_ibm-vpc-block-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrole_ibm-vpc-block-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_ibm-vpc-block-csi-driver-operator-clusterrolebinding.yaml",
+		}
+		csiDriverConfig.MgmtOperatorConfigAsset = "csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/v1_configmap_ibm-vpc-block-csi-driver-operator-config.yaml"
+		csiDriverConfig.MgmtStaticAssets = []string{
+			"csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_role_ibm-vpc-block-csi-driver-operator-role.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/v1_serviceaccount_ibm-vpc-block-csi-driver-operator.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/v1_service_ibm-vpc-block-csi-driver-operator-metrics.yaml",
+			"csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_rolebinding_ibm-vpc-block-csi-driver-operator-rolebinding.yaml",
+		}
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/apps_v1_deployment_ibm-vpc-block-csi-driver-operator.yaml"
+		csiDriverConfig.CRAsset = "csidriveroperators/ibm-vpc-block/hypershift/guest/generated/operator.openshift.io_v1_clustercsidriver_vpc.block.csi.ibm.io.yaml"
+	}
+
+	csiDriverConfig.CSIDriverDeploymentName = getCSIDriverDeploymentName(csiDriverConfig.DeploymentAsset)
+
+	return csiDriverConfig
 }
@@ -6,10 +6,6 @@ import (
 
 	v1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
-	"github.com/openshift/library-go/pkg/controller/factory"
-	"github.com/openshift/library-go/pkg/operator/events"
-	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
-	"k8s.io/klog/v2"
 )
 
 const (
@@ -21,7 +17,7 @@ const (
 	envManilaDriverControlPlangeImage = "MANILA_DRIVER_CONTROL_PLANE_IMAGE"
 )
 
-func GetOpenStackManilaOperatorConfig(isHypershift bool, clients *csoclients.Clients, recorder events.Recorder) CSIOperatorConfig {
+func GetOpenStackManilaOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	pairs := []string{
 		"${OPERATOR_IMAGE}", os.Getenv(envManilaDriverOperatorImage),
 		"${DRIVER_IMAGE}", os.Getenv(envManilaDriverImage),
@@ -30,19 +26,17 @@ func GetOpenStackManilaOperatorConfig(isHypershift bool, clients *csoclients.Cli
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   "manila.csi.openstack.org",
-		ConditionPrefix: "Manila",
-		Platform:        v1.OpenStackPlatformType,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   true,
+		CSIDriverName:      "manila.csi.openstack.org",
+		NodeDaemonSetNames: []string{"openstack-manila-csi-nodeplugin", "csi-nodeplugin-nfsplugin"},
+		ConditionPrefix:    "Manila",
+		Platform:           v1.OpenStackPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      true,
 	}
 	if !isHypershift {
-		csiDriverConfig.ExtraControllers = []factory.Controller{
-			newCertificateSyncerOrDie(clients, recorder),
-		}
-	}
-
-	if !isHypershift {
+		csiDriverConfig.CloudConfigSyncTarget = CloudConfigName
+		csiDriverConfig.CredentialsSecretName = "manila-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/openstack-manila/standalone/generated/openshift-cluster-csi-drivers_v1_configmap_manila-csi-driver-operator-config.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/openstack-manila/standalone/generated/v1_namespace_openshift-manila-csi-driver.yaml",
@@ -76,30 +70,3 @@ func GetOpenStackManilaOperatorConfig(isHypershift bool, clients *csoclients.Cli
 
 	return csiDriverConfig
 }
-
-func newCertificateSyncerOrDie(clients *csoclients.Clients, recorder events.Recorder) factory.Controller {
-	// sync config map with OpenStack CA certificate to the operator namespace,
-	// so the operator can get it as a ConfigMap volume.
-	srcConfigMap := resourcesynccontroller.ResourceLocation{
-		Namespace: csoclients.CloudConfigNamespace,
-		Name:      CloudConfigName,
-	}
-	dstConfigMap := resourcesynccontroller.ResourceLocation{
-		Namespace: csoclients.CSIOperatorNamespace,
-		Name:      CloudConfigName,
-	}
-	certController := resourcesynccontroller.NewResourceSyncController(
-		"openshift-storage",
-		clients.OperatorClient,
-		clients.KubeInformers,
-		clients.KubeClient.CoreV1(),
-		clients.KubeClient.CoreV1(),
-		recorder)
-	err := certController.SyncConfigMap(dstConfigMap, srcConfigMap)
-	if err != nil {
-		// This can fail if provided clients.KubeInformers does not watch requested namespaces,
-		// which is programmatic error.
-		klog.Fatalf("Failed to start Manila CA certificate sync controller: %s", err)
-	}
-	return certController
-}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -21,14 +22,17 @@ func GetPowerVSBlockCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	}
 
 	csiDriverConfig := CSIOperatorConfig{
-		CSIDriverName:   PowerVSBlockCSIDriverName,
-		ConditionPrefix: "PowerVSBlock",
-		Platform:        configv1.PowerVSPlatformType,
-		ImageReplacer:   strings.NewReplacer(pairs...),
-		AllowDisabled:   false,
+		CSIDriverName:      PowerVSBlockCSIDriverName,
+		NodeDaemonSetNames: []string{"ibm-powervs-block-csi-driver-node"},
+		ConditionPrefix:    "PowerVSBlock",
+		Platform:           configv1.PowerVSPlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      false,
 	}
 
 	if !isHypershift {
+		csiDriverConfig.CredentialsSecretName = "ibm-powervs-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
 		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/powervs-block/standalone/03_configmap.yaml"
 		csiDriverConfig.StaticAssets = []string{
 			"csidriveroperators/powervs-block/standalone/01_sa.yaml",
@@ -0,0 +1,158 @@
+package csioperatorclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ShortTermTokenDir is where the projected service account token is mounted in
+	// the CSI driver operator containers. ccoctl puts the token path under this directory
+	// into the credentials Secrets.
+	ShortTermTokenDir  = "/var/run/secrets/openshift/serviceaccount"
+	ShortTermTokenPath = ShortTermTokenDir + "/token"
+
+	// shortTermTokenAudience is the audience that ccoctl configures in the cloud identity providers.
+	shortTermTokenAudience = "openshift"
+)
+
+// awsShortTermCredentials returns settings for AWS Security Token Service. The credentials
+// Secret contains an AWS config file with role_arn and web_identity_token_file.
+func awsShortTermCredentials() *ShortTermCredentials {
+	return &ShortTermCredentials{
+		TokenAudience: shortTermTokenAudience,
+		Env: []corev1.EnvVar{
+			{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: ShortTermTokenPath},
+		},
+		IsShortTermSecret: func(secret *corev1.Secret) bool {
+			profiles, err := parseAWSConfig(string(secret.Data["credentials"]))
+			if err != nil {
+				return false
+			}
+			return profiles["default"]["web_identity_token_file"] != ""
+		},
+		CheckSecret: func(secret *corev1.Secret) error {
+			profiles, err := parseAWSConfig(string(secret.Data["credentials"]))
+			if err != nil {
+				return fmt.Errorf("failed to parse key credentials: %w", err)
+			}
+			roleARN := profiles["default"]["role_arn"]
+			if roleARN == "" {
+				return fmt.Errorf("key credentials does not contain role_arn in the default profile")
+			}
+			if !strings.HasPrefix(roleARN, "arn:") {
+				return fmt.Errorf("key credentials has invalid role_arn %q", roleARN)
+			}
+			return nil
+		},
+	}
+}
+
+// parseAWSConfig parses an AWS shared config or credentials file into key-value pairs
+// of its profiles. Both "[name]" and "[profile name]" sections are accepted, as the AWS SDKs do.
+func parseAWSConfig(data string) (map[string]map[string]string, error) {
+	profiles := map[string]map[string]string{}
+	var profile map[string]string
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section %q", i+1, line)
+			}
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			profile = profiles[name]
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("line %d: key %s is outside of a profile", i+1, strings.TrimSpace(key))
+		}
+		profile[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return profiles, nil
+}
+
+// azureShortTermCredentials returns settings for Azure Workload Identity. The credentials
+// Secret contains the client and tenant of the managed identity and the path to the token.
+func azureShortTermCredentials(secretName string) *ShortTermCredentials {
+	secretKeyRef := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}
+	}
+	return &ShortTermCredentials{
+		TokenAudience: shortTermTokenAudience,
+		Env: []corev1.EnvVar{
+			{Name: "AZURE_FEDERATED_TOKEN_FILE", Value: ShortTermTokenPath},
+			{Name: "AZURE_CLIENT_ID", ValueFrom: secretKeyRef("azure_client_id")},
+			{Name: "AZURE_TENANT_ID", ValueFrom: secretKeyRef("azure_tenant_id")},
+		},
+		IsShortTermSecret: func(secret *corev1.Secret) bool {
+			return len(secret.Data["azure_federated_token_file"]) > 0
+		},
+		CheckSecret: func(secret *corev1.Secret) error {
+			var missing []string
+			for _, key := range []string{"azure_client_id", "azure_tenant_id"} {
+				if len(secret.Data[key]) == 0 {
+					missing = append(missing, key)
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("missing keys %s", strings.Join(missing, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// gcpShortTermCredentials returns settings for GCP Workload Identity Federation. The credentials
+// Secret contains an external_account credentials file that impersonates a service account.
+func gcpShortTermCredentials() *ShortTermCredentials {
+	return &ShortTermCredentials{
+		TokenAudience: shortTermTokenAudience,
+		IsShortTermSecret: func(secret *corev1.Secret) bool {
+			credentials := struct {
+				Type string `json:"type"`
+			}{}
+			if err := json.Unmarshal(secret.Data["service_account.json"], &credentials); err != nil {
+				return false
+			}
+			return credentials.Type == "external_account"
+		},
+		CheckSecret: func(secret *corev1.Secret) error {
+			credentials := struct {
+				Type                           string `json:"type"`
+				Audience                       string `json:"audience"`
+				ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+			}{}
+			if err := json.Unmarshal(secret.Data["service_account.json"], &credentials); err != nil {
+				return fmt.Errorf("failed to parse key service_account.json: %w", err)
+			}
+			switch {
+			case credentials.Type != "external_account":
+				return fmt.Errorf("key service_account.json has type %q, expected \"external_account\"", credentials.Type)
+			case credentials.Audience == "":
+				return fmt.Errorf("key service_account.json does not contain the workload identity pool audience")
+			case credentials.ServiceAccountImpersonationURL == "":
+				return fmt.Errorf("key service_account.json does not contain the service account to impersonate")
+			}
+			return nil
+		},
+	}
+}
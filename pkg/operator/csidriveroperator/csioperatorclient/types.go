@@ -5,6 +5,7 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -20,6 +21,9 @@ type CSIOperatorConfig struct {
 	CSIDriverName string
 	// Name of the CSI driver operator deployment (such as aws-ebs-csi-driver-operator)
 	CSIDriverDeploymentName string
+	// Names of the CSI driver node DaemonSets (such as aws-ebs-csi-driver-node) created
+	// by the CSI driver operator in openshift-cluster-csi-drivers namespace.
+	NodeDaemonSetNames []string
 	// Short name of the driver, used to prefix conditions.
 	ConditionPrefix string
 	// Platform where the driver should run.
@@ -56,6 +60,10 @@ type CSIOperatorConfig struct {
 	CRAsset string
 	// ServiceMonitorAsset is the name of the bindata asset with the ServiceMonitor
 	ServiceMonitorAsset string
+	// PrometheusRuleAsset is the name of the bindata asset with the PrometheusRule of the
+	// CSI driver in standalone clusters. The rule is removed when the ClusterCSIDriver is
+	// Removed or when the driver alerts are disabled in csi-driver-alerts ConfigMap.
+	PrometheusRuleAsset string
 	// DeploymentAsset is name of the bindata asset with Deployment of the
 	// operator. It will get updated by OCS in this way:
 	// - ImageReplacer this CSIOperatorConfig is run.
@@ -70,6 +78,39 @@ type CSIOperatorConfig struct {
 	AllowDisabled bool
 	// Extra controllers to start with the CSI driver operator
 	ExtraControllers []factory.Controller
+	// CloudConfigSyncTarget is the name of a ConfigMap in the CSI driver operator namespace
+	// to which the cluster cloud config, openshift-config/<Infrastructure.Spec.CloudConfig.Name>,
+	// is synced. The cloud config is not synced when empty.
+	// It is empty in HyperShift: guest cluster data must not be copied to the management
+	// cluster, the operators mount the cloud config provided by HyperShift.
+	CloudConfigSyncTarget string
+	// CredentialsSecretName and CredentialsSecretNamespace identify the Secret with cloud
+	// credentials minted by cloud-credential-operator from the driver CredentialsRequest
+	// in standalone clusters. The operator Deployment is not created until the Secret exists
+	// and it is restarted when the Secret changes. Nothing is waited for when empty.
+	CredentialsSecretName      string
+	CredentialsSecretNamespace string
+	// ShortTermCredentials is set when the CSI driver operator supports short-term credentials
+	// from projected service account tokens, such as AWS STS, Azure Workload Identity or
+	// GCP Workload Identity Federation. It is used only with CredentialsSecretName.
+	ShortTermCredentials *ShortTermCredentials
 	// Run the CSI driver operator only when given FeatureGate is enabled
 	RequireFeatureGate configv1.FeatureGateName
 }
+
+// ShortTermCredentials describes how a CSI driver operator gets short-term cloud credentials.
+// When the credentials Secret is for short-term credentials, the projected service account
+// token volume and Env are added to the operator Deployment.
+type ShortTermCredentials struct {
+	// TokenAudience is the audience of the projected service account token.
+	TokenAudience string
+	// Env is added to all containers of the operator Deployment.
+	Env []corev1.EnvVar
+	// IsShortTermSecret returns true when the credentials Secret tells the cloud SDK to
+	// exchange the service account token for credentials. Secrets with long-lived
+	// credentials return false, also in clusters with short-term credentials.
+	IsShortTermSecret func(secret *corev1.Secret) bool
+	// CheckSecret returns an error when a short-term credentials Secret does not contain
+	// the cloud role or identity to use with the token.
+	CheckSecret func(secret *corev1.Secret) error
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 )
 
 const (
@@ -14,34 +15,62 @@ const (
 	envVMWareVsphereDriverSyncerImage   = "VMWARE_VSPHERE_SYNCER_IMAGE"
 )
 
-func GetVMwareVSphereCSIOperatorConfig() CSIOperatorConfig {
+func GetVMwareVSphereCSIOperatorConfig(isHypershift bool) CSIOperatorConfig {
 	pairs := []string{
 		"${OPERATOR_IMAGE}", os.Getenv(envVMwareVSphereDriverOperatorImage),
 		"${DRIVER_IMAGE}", os.Getenv(envVMwareVSphereDriverImage),
 		"${VMWARE_VSPHERE_SYNCER_IMAGE}", os.Getenv(envVMWareVsphereDriverSyncerImage),
 	}
 
-	return CSIOperatorConfig{
-		CSIDriverName:                 VMwareVSphereDriverName,
-		ConditionPrefix:               "VSphere",
-		Platform:                      configv1.VSpherePlatformType,
-		StandaloneOperatorConfigAsset: "csidriveroperators/vsphere/03_configmap.yaml",
-		StaticAssets: []string{
-			"csidriveroperators/vsphere/02_configmap.yaml",
-			"csidriveroperators/vsphere/03_sa.yaml",
-			"csidriveroperators/vsphere/04_role.yaml",
-			"csidriveroperators/vsphere/05_rolebinding.yaml",
-			"csidriveroperators/vsphere/06_clusterrole.yaml",
-			"csidriveroperators/vsphere/07_clusterrolebinding.yaml",
-			"csidriveroperators/vsphere/11_service.yaml",
-			"csidriveroperators/vsphere/13_prometheus_role.yaml",
-			"csidriveroperators/vsphere/14_prometheus_rolebinding.yaml",
-			"csidriveroperators/vsphere/15_prometheusrules.yaml",
-		},
-		ServiceMonitorAsset: "csidriveroperators/vsphere/12_servicemonitor.yaml",
-		CRAsset:             "csidriveroperators/vsphere/09_cr.yaml",
-		DeploymentAsset:     "csidriveroperators/vsphere/08_deployment.yaml",
-		ImageReplacer:       strings.NewReplacer(pairs...),
-		AllowDisabled:       true,
+	csiDriverConfig := CSIOperatorConfig{
+		CSIDriverName:      VMwareVSphereDriverName,
+		NodeDaemonSetNames: []string{"vmware-vsphere-csi-driver-node"},
+		ConditionPrefix:    "VSphere",
+		Platform:           configv1.VSpherePlatformType,
+		ImageReplacer:      strings.NewReplacer(pairs...),
+		AllowDisabled:      true,
 	}
+
+	if !isHypershift {
+		csiDriverConfig.CredentialsSecretName = "vmware-vsphere-cloud-credentials"
+		csiDriverConfig.CredentialsSecretNamespace = csoclients.CSIOperatorNamespace
+		csiDriverConfig.StandaloneOperatorConfigAsset = "csidriveroperators/vsphere/standalone/generated/v1_configmap_vmware-vsphere-csi-driver-operator-config.yaml"
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/vsphere/standalone/generated/v1_configmap_vsphere-csi-driver-operator-trusted-ca-bundle.yaml",
+			"csidriveroperators/vsphere/standalone/generated/v1_serviceaccount_vmware-vsphere-csi-driver-operator.yaml",
+			"csidriveroperators/vsphere/standalone/generated/rbac.authorization.k8s.io_v1_role_vmware-vsphere-csi-driver-operator-role.yaml",
+			"csidriveroperators/vsphere/standalone/generated/rbac.authorization.k8s.io_v1_rolebinding_vmware-vsphere-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/vsphere/standalone/generated/rbac.authorization.k8s.io_v1_clusterrole_vmware-vsphere-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/vsphere/standalone/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_vmware-vsphere-csi-driver-operator-clusterrolebinding.yaml",
+			"csidriveroperators/vsphere/standalone/generated/v1_service_vmware-vsphere-csi-driver-operator-metrics.yaml",
+			"csidriveroperators/vsphere/standalone/13_prometheus_role.yaml",
+			"csidriveroperators/vsphere/standalone/14_prometheus_rolebinding.yaml",
+		}
+		csiDriverConfig.ServiceMonitorAsset = "csidriveroperators/vsphere/standalone/12_servicemonitor.yaml"
+		csiDriverConfig.PrometheusRuleAsset = "csidriveroperators/vsphere/standalone/15_prometheusrules.yaml"
+		csiDriverConfig.CRAsset = "csidriveroperators/vsphere/standalone/generated/operator.openshift.io_v1_clustercsidriver_csi.vsphere.vmware.com.yaml"
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/vsphere/standalone/generated/apps_v1_deployment_vmware-vsphere-csi-driver-operator.yaml"
+	} else {
+		csiDriverConfig.StaticAssets = []string{
+			"csidriveroperators/vsphere/hypershift/guest/generated/v1_serviceaccount_vmware-vsphere-csi-driver-operator.yaml",
+			"csidriveroperators/vsphere/hypershift/guest/generated/rbac.authorization.k8s.io_v1_role_vmware-vsphere-csi-driver-operator-role.yaml",
+			"csidriveroperators/vsphere/hypershift/guest/generated/rbac.authorization.k8s.io_v1_rolebinding_vmware-vsphere-csi-driver-operator-rolebinding.yaml",
+			"csidriveroperators/vsphere/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrole_vmware-vsphere-csi-driver-operator-clusterrole.yaml",
+			"csidriveroperators/vsphere/hypershift/guest/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_vmware-vsphere-csi-driver-operator-clusterrolebinding.yaml",
+		}
+		csiDriverConfig.MgmtOperatorConfigAsset = "csidriveroperators/vsphere/hypershift/mgmt/generated/v1_configmap_vmware-vsphere-csi-driver-operator-config.yaml"
+		csiDriverConfig.MgmtStaticAssets = []string{
+			"csidriveroperators/vsphere/hypershift/mgmt/generated/v1_configmap_vsphere-csi-driver-operator-trusted-ca-bundle.yaml",
+			"csidriveroperators/vsphere/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_role_vmware-vsphere-csi-driver-operator-role.yaml",
+			"csidriveroperators/vsphere/hypershift/mgmt/generated/v1_serviceaccount_vmware-vsphere-csi-driver-operator.yaml",
+			"csidriveroperators/vsphere/hypershift/mgmt/generated/v1_service_vmware-vsphere-csi-driver-operator-metrics.yaml",
+			"csidriveroperators/vsphere/hypershift/mgmt/generated/rbac.authorization.k8s.io_v1_rolebinding_vmware-vsphere-csi-driver-operator-rolebinding.yaml",
+		}
+		csiDriverConfig.DeploymentAsset = "csidriveroperators/vsphere/hypershift/mgmt/generated/apps_v1_deployment_vmware-vsphere-csi-driver-operator.yaml"
+		csiDriverConfig.CRAsset = "csidriveroperators/vsphere/hypershift/guest/generated/operator.openshift.io_v1_clustercsidriver_csi.vsphere.vmware.com.yaml"
+	}
+
+	csiDriverConfig.CSIDriverDeploymentName = getCSIDriverDeploymentName(csiDriverConfig.DeploymentAsset)
+
+	return csiDriverConfig
 }
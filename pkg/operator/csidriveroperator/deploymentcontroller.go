@@ -8,6 +8,8 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	sigsyaml "sigs.k8s.io/yaml"
@@ -16,6 +18,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/csi/csidrivercontrollerservicecontroller"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
@@ -32,6 +35,9 @@ import (
 
 const (
 	deploymentControllerName = "CSIDriverOperatorDeployment"
+
+	// waitingForCredentialsReasonSuffix is appended to the CSI driver ConditionPrefix.
+	waitingForCredentialsReasonSuffix = "WaitingForCredentials"
 )
 
 type CommonCSIDeploymentController struct {
@@ -81,7 +87,9 @@ func (c *CommonCSIDeploymentController) preCheckSync(
 	return true, opStatus, opSpec, nil
 }
 
-func (c *CommonCSIDeploymentController) postSync(ctx context.Context, deployment *appsv1.Deployment) error {
+// postSync updates the Progressing condition of the Deployment. When operatorConfigHash is
+// set, it also reports whether the Deployment already runs with the current operator config.
+func (c *CommonCSIDeploymentController) postSync(ctx context.Context, deployment *appsv1.Deployment, operatorConfigHash string) error {
 	progressingCondition := operatorv1.OperatorCondition{
 		Type:   c.name + operatorv1.OperatorStatusTypeProgressing,
 		Status: operatorv1.ConditionFalse,
@@ -98,12 +106,15 @@ func (c *CommonCSIDeploymentController) postSync(ctx context.Context, deployment
 		return nil
 	}
 
-	_, _, err := v1helpers.UpdateStatus(
-		ctx,
-		c.operatorClient,
+	updateFuncs := []v1helpers.UpdateStatusFunc{
 		updateStatusFn,
 		v1helpers.UpdateConditionFn(progressingCondition),
-	)
+	}
+	if operatorConfigHash != "" {
+		updateFuncs = append(updateFuncs, v1helpers.UpdateConditionFn(csotls.TLSProfileProgressingCondition(c.name, deployment, operatorConfigHash)))
+	}
+
+	_, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, updateFuncs...)
 	return err
 }
 
@@ -136,12 +147,19 @@ func initCommonDeploymentParams(
 // It produces following Conditions:
 // <CSI driver name>CSIDriverOperatorDeploymentProgressing
 // <CSI driver name>CSIDriverOperatorDeploymentDegraded
+// When the CSI driver operator needs cloud credentials, the Deployment is created only after
+// cloud-credential-operator creates the credentials Secret. Until then, the Progressing
+// condition has <CSI driver name>WaitingForCredentials reason.
+// When the credentials Secret is for short-term credentials (AWS STS, Azure Workload Identity,
+// GCP WIF), it mounts a projected service account token into the Deployment and reports
+// such Secret without cloud role or identity in <CSI driver name>ShortTermCredentialsDegraded.
 // This controller doesn't set the Available condition to avoid prematurely cascading
 // up to the clusteroperator CR a potential Available=false. On the other hand it
 // does a better in making sure the Degraded condition is properly set if
 // Deployment isn't healthy.
 type CSIDriverOperatorDeploymentController struct {
 	CommonCSIDeploymentController
+	credentialsSecretInformer coreinformers.SecretInformer
 }
 
 var _ factory.Controller = &CSIDriverOperatorDeploymentController{}
@@ -157,11 +175,18 @@ func NewCSIDriverOperatorDeploymentController(
 	c := &CSIDriverOperatorDeploymentController{
 		CommonCSIDeploymentController: initCommonDeploymentParams(clients, csiOperatorConfig, resyncInterval, versionGetter, targetVersion, eventRecorder),
 	}
+	if csiOperatorConfig.CredentialsSecretName != "" {
+		c.credentialsSecretInformer = clients.KubeInformers.InformersFor(csiOperatorConfig.CredentialsSecretNamespace).Core().V1().Secrets()
+	}
 	f := c.initController(func(f *factory.Factory) {
 		f.WithInformers(
 			c.commonClients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Apps().V1().Deployments().Informer(),
+			c.commonClients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Core().V1().ConfigMaps().Informer(),
 			c.commonClients.ConfigInformers.Config().V1().Infrastructures().Informer(),
 			c.commonClients.ConfigInformers.Config().V1().APIServers().Informer())
+		if c.credentialsSecretInformer != nil {
+			f.WithInformers(c.credentialsSecretInformer.Informer())
+		}
 	})
 	c.factory = f
 	return c
@@ -180,6 +205,18 @@ func (c *CSIDriverOperatorDeploymentController) Sync(ctx context.Context, syncCt
 		return nil
 	}
 
+	hasCredentials, err := c.hasCredentials()
+	if err != nil {
+		return err
+	}
+	if !hasCredentials {
+		return c.waitForCredentials(ctx)
+	}
+	shortTermCredentials, err := c.syncShortTermCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
 	replacers := []*strings.Replacer{sidecarReplacer}
 	// Replace images
 	if c.csiOperatorConfig.ImageReplacer != nil {
@@ -205,10 +242,38 @@ func (c *CSIDriverOperatorDeploymentController) Sync(ctx context.Context, syncCt
 		requiredCopy.Spec.Template.Spec.NodeSelector = map[string]string{}
 	}
 
+	// Restart the pods when the TLS profile in the operator config changes
+	var operatorConfigHash string
 	if c.csiOperatorConfig.StandaloneOperatorConfigAsset != "" {
-		if err := c.reconcileOperatorConfigMap(ctx); err != nil {
+		operatorConfigHash, err = c.reconcileOperatorConfigMap(ctx)
+		if err != nil {
 			return err
 		}
+		csotls.SetOperatorConfigHash(requiredCopy, operatorConfigHash)
+	}
+
+	// Restart the pods when the credentials are rotated
+	if c.credentialsSecretInformer != nil {
+		hook := csidrivercontrollerservicecontroller.WithSecretHashAnnotationHook(
+			c.csiOperatorConfig.CredentialsSecretNamespace,
+			c.csiOperatorConfig.CredentialsSecretName,
+			c.credentialsSecretInformer)
+		if err := hook(opSpec, requiredCopy); err != nil {
+			return fmt.Errorf("failed to add credentials hash to deployment: %w", err)
+		}
+	}
+
+	if shortTermCredentials {
+		injectShortTermCredentials(requiredCopy, c.csiOperatorConfig.ShortTermCredentials)
+	}
+
+	// Trust the cluster CA bundle, e.g. of a TLS-intercepting proxy
+	trustedCABundle, err := c.reconcileTrustedCABundleConfigMap(ctx)
+	if err != nil {
+		return err
+	}
+	if err := injectTrustedCABundle(requiredCopy, trustedCABundle); err != nil {
+		return err
 	}
 
 	lastGeneration := resourcemerge.ExpectedDeploymentGeneration(requiredCopy, opStatus.Generations)
@@ -217,13 +282,70 @@ func (c *CSIDriverOperatorDeploymentController) Sync(ctx context.Context, syncCt
 		return err
 	}
 
-	err = c.postSync(ctx, deployment)
+	err = c.postSync(ctx, deployment, operatorConfigHash)
 	if err != nil {
 		return err
 	}
 	return checkDeploymentHealth(ctx, c.kubeClient.AppsV1(), deployment)
 }
 
+// hasCredentials returns true when the CSI driver operator does not need cloud credentials
+// or when their Secret exists.
+func (c *CSIDriverOperatorDeploymentController) hasCredentials() (bool, error) {
+	if c.credentialsSecretInformer == nil {
+		return true, nil
+	}
+	_, err := c.credentialsSecretInformer.Lister().Secrets(c.csiOperatorConfig.CredentialsSecretNamespace).Get(c.csiOperatorConfig.CredentialsSecretName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitForCredentials reports that the Deployment waits for the credentials Secret.
+func (c *CSIDriverOperatorDeploymentController) waitForCredentials(ctx context.Context) error {
+	klog.V(2).Infof("Waiting for credentials Secret %s/%s of %s", c.csiOperatorConfig.CredentialsSecretNamespace, c.csiOperatorConfig.CredentialsSecretName, c.csiOperatorConfig.CSIDriverName)
+	progressingCondition := operatorv1.OperatorCondition{
+		Type:   c.name + operatorv1.OperatorStatusTypeProgressing,
+		Status: operatorv1.ConditionTrue,
+		Reason: c.csiOperatorConfig.ConditionPrefix + waitingForCredentialsReasonSuffix,
+		Message: fmt.Sprintf("Waiting for cloud-credential-operator to provide credentials Secret %s/%s",
+			c.csiOperatorConfig.CredentialsSecretNamespace, c.csiOperatorConfig.CredentialsSecretName),
+	}
+	_, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(progressingCondition))
+	return err
+}
+
+// syncShortTermCredentials detects from the credentials Secret whether the CSI driver operator
+// should use short-term credentials and checks that the Secret is usable with them. A Secret
+// without cloud role or identity data is reported in <CSI driver name>ShortTermCredentialsDegraded
+// condition, the Deployment is still applied so that other changes are not blocked.
+// The cluster credentials mode is not checked: clusters in Manual mode may use long-lived
+// credentials too.
+func (c *CSIDriverOperatorDeploymentController) syncShortTermCredentials(ctx context.Context) (bool, error) {
+	if c.credentialsSecretInformer == nil || c.csiOperatorConfig.ShortTermCredentials == nil {
+		return false, nil
+	}
+	secret, err := c.credentialsSecretInformer.Lister().Secrets(c.csiOperatorConfig.CredentialsSecretNamespace).Get(c.csiOperatorConfig.CredentialsSecretName)
+	if err != nil {
+		return false, err
+	}
+
+	shortTerm := c.csiOperatorConfig.ShortTermCredentials.IsShortTermSecret(secret)
+	var checkErr error
+	if shortTerm {
+		checkErr = c.csiOperatorConfig.ShortTermCredentials.CheckSecret(secret)
+	}
+	cond := shortTermCredentialsCondition(c.name, secret, checkErr)
+	if _, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); err != nil {
+		return false, err
+	}
+	return shortTerm, nil
+}
+
 func (c *CSIDriverOperatorDeploymentController) Run(ctx context.Context, workers int) {
 	// This adds event handlers to informers.
 	ctrl := c.factory.WithSync(c.Sync).ToController(c.Name(), c.eventRecorder)
@@ -270,32 +392,51 @@ func hasFinishedProgressing(deployment *appsv1.Deployment) bool {
 
 // reconcileOperatorConfigMap reads the standalone ConfigMap asset for name/namespace, builds a
 // typed GenericOperatorConfig with TLS settings from APIServer/cluster, and applies it.
-func (c *CSIDriverOperatorDeploymentController) reconcileOperatorConfigMap(ctx context.Context) error {
+// It returns hash of the applied ConfigMap.
+func (c *CSIDriverOperatorDeploymentController) reconcileOperatorConfigMap(ctx context.Context) (string, error) {
 	assetBytes, err := assets.ReadFile(c.csiOperatorConfig.StandaloneOperatorConfigAsset)
 	if err != nil {
-		return fmt.Errorf("failed to read operator config asset: %w", err)
+		return "", fmt.Errorf("failed to read operator config asset: %w", err)
 	}
 
 	cm := &corev1.ConfigMap{}
 	if err := sigsyaml.Unmarshal(assetBytes, cm); err != nil {
-		return fmt.Errorf("failed to decode operator config ConfigMap: %w", err)
+		return "", fmt.Errorf("failed to decode operator config ConfigMap: %w", err)
 	}
 
 	apiServer, err := c.apiServerLister.Get("cluster")
 	if err != nil {
-		return fmt.Errorf("failed to get APIServer cluster: %w", err)
+		return "", fmt.Errorf("failed to get APIServer cluster: %w", err)
 	}
-	minTLSVersion, cipherSuites := csotls.TLSSettingsFromProfile(apiServer.Spec.TLSSecurityProfile)
-
-	yaml, err := csotls.OperatorConfigYAML(minTLSVersion, cipherSuites)
+	yaml, err := csotls.OperatorConfigYAML(csotls.TLSSettingsFromProfile(apiServer.Spec.TLSSecurityProfile))
 	if err != nil {
-		return err
+		return "", err
 	}
 	if cm.Data == nil {
 		cm.Data = make(map[string]string)
 	}
 	cm.Data["config.yaml"] = yaml
 
-	_, _, err = resourceapply.ApplyConfigMap(ctx, c.commonClients.KubeClient.CoreV1(), c.eventRecorder, cm)
-	return err
+	applied, _, err := resourceapply.ApplyConfigMap(ctx, c.commonClients.KubeClient.CoreV1(), c.eventRecorder, cm)
+	if err != nil {
+		return "", err
+	}
+	return csotls.OperatorConfigHash(applied)
+}
+
+// reconcileTrustedCABundleConfigMap applies the trusted CA bundle ConfigMap shared by all CSI
+// driver operators and returns it with the CA bundle injected by the cluster network operator.
+func (c *CSIDriverOperatorDeploymentController) reconcileTrustedCABundleConfigMap(ctx context.Context) (*corev1.ConfigMap, error) {
+	assetBytes, err := assets.ReadFile(trustedCABundleAsset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted CA bundle asset: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := sigsyaml.Unmarshal(assetBytes, cm); err != nil {
+		return nil, fmt.Errorf("failed to decode trusted CA bundle ConfigMap: %w", err)
+	}
+
+	applied, _, err := resourceapply.ApplyConfigMap(ctx, c.commonClients.KubeClient.CoreV1(), c.eventRecorder, cm)
+	return applied, err
 }
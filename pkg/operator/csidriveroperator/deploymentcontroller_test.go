@@ -0,0 +1,198 @@
+package csidriveroperator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func credentialsSecret(data string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: csoclients.CSIOperatorNamespace, Name: "ebs-cloud-credentials"},
+		Data:       map[string][]byte{"credentials": []byte(data)},
+	}
+}
+
+func credentialsHash(deployment *appsv1.Deployment) string {
+	for key, value := range deployment.Spec.Template.Annotations {
+		if strings.HasPrefix(key, "operator.openshift.io/dep-") {
+			return value
+		}
+	}
+	return ""
+}
+
+func syncCredentialsTestController(t *testing.T, coreObjects ...runtime.Object) (*csoclients.Clients, error) {
+	clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+		CoreObjects:     coreObjects,
+		OperatorObjects: []runtime.Object{csoclients.GetCR()},
+		ConfigObjects: []runtime.Object{
+			&configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: infraConfigName}},
+			&configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+		},
+	})
+	recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+	ctrl := NewCSIDriverOperatorDeploymentController(clients, csioperatorclient.GetAWSEBSCSIOperatorConfig(false), status.NewVersionGetter(), "4.99.0", recorder, time.Hour)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	csoclients.StartInformers(clients, stopCh)
+	if !cache.WaitForCacheSync(stopCh,
+		clients.ConfigInformers.Config().V1().Infrastructures().Informer().HasSynced,
+		clients.ConfigInformers.Config().V1().APIServers().Informer().HasSynced,
+		clients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Core().V1().Secrets().Informer().HasSynced,
+	) {
+		t.Fatal("timed out waiting for informer cache sync")
+	}
+	return clients, ctrl.Sync(context.TODO(), nil)
+}
+
+func TestDeploymentControllerCredentials(t *testing.T) {
+	t.Run("missing credentials", func(t *testing.T) {
+		clients, err := syncCredentialsTestController(t)
+		assert.NoError(t, err)
+
+		_, err = clients.KubeClient.AppsV1().Deployments(csoclients.CSIOperatorNamespace).Get(context.TODO(), "aws-ebs-csi-driver-operator", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err), "Deployment must not be created without credentials, got: %v", err)
+
+		_, opStatus, _, err := clients.OperatorClient.GetOperatorState()
+		assert.NoError(t, err)
+		cond := v1helpers.FindOperatorCondition(opStatus.Conditions, "AWSEBS"+operatorv1.OperatorStatusTypeProgressing)
+		if assert.NotNil(t, cond) {
+			assert.Equal(t, operatorv1.ConditionTrue, cond.Status)
+			assert.Equal(t, "AWSEBSWaitingForCredentials", cond.Reason)
+			assert.Contains(t, cond.Message, "openshift-cluster-csi-drivers/ebs-cloud-credentials")
+		}
+	})
+
+	t.Run("credentials available", func(t *testing.T) {
+		clients, err := syncCredentialsTestController(t, credentialsSecret("key1"))
+		assert.NoError(t, err)
+
+		deployment, err := clients.KubeClient.AppsV1().Deployments(csoclients.CSIOperatorNamespace).Get(context.TODO(), "aws-ebs-csi-driver-operator", metav1.GetOptions{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		hash := credentialsHash(deployment)
+		assert.NotEmpty(t, hash, "Deployment must have credentials hash annotation")
+
+		_, opStatus, _, err := clients.OperatorClient.GetOperatorState()
+		assert.NoError(t, err)
+		cond := v1helpers.FindOperatorCondition(opStatus.Conditions, "AWSEBS"+operatorv1.OperatorStatusTypeProgressing)
+		if assert.NotNil(t, cond) {
+			assert.NotEqual(t, "AWSEBS"+waitingForCredentialsReasonSuffix, cond.Reason)
+		}
+
+		clients, err = syncCredentialsTestController(t, credentialsSecret("key2"))
+		assert.NoError(t, err)
+		rotated, err := clients.KubeClient.AppsV1().Deployments(csoclients.CSIOperatorNamespace).Get(context.TODO(), "aws-ebs-csi-driver-operator", metav1.GetOptions{})
+		if assert.NoError(t, err) {
+			assert.NotEqual(t, hash, credentialsHash(rotated), "credentials rotation must roll out the Deployment")
+		}
+	})
+}
+
+func TestDeploymentControllerShortTermCredentials(t *testing.T) {
+	stsSecret := credentialsSecret("[default]\nrole_arn = arn:aws:iam::123456789012:role/ebs\nweb_identity_token_file = " + csioperatorclient.ShortTermTokenPath + "\n")
+
+	tests := []struct {
+		name              string
+		secret            *corev1.Secret
+		expectToken       bool
+		expectedCondition operatorv1.ConditionStatus
+	}{
+		{
+			name:              "long-term credentials",
+			secret:            credentialsSecret("[default]\naws_access_key_id = foo\n"),
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name:              "long-term credentials with assumed role",
+			secret:            credentialsSecret("[default]\nrole_arn = arn:aws:iam::123456789012:role/ebs\nsource_profile = base\n[base]\naws_access_key_id = foo\n"),
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name:              "STS",
+			secret:            stsSecret,
+			expectToken:       true,
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name:              "STS without role",
+			secret:            credentialsSecret("[default]\nweb_identity_token_file = " + csioperatorclient.ShortTermTokenPath + "\n"),
+			expectToken:       true,
+			expectedCondition: operatorv1.ConditionTrue,
+		},
+		{
+			name:              "STS with commented out role",
+			secret:            credentialsSecret("[default]\n# role_arn = arn:aws:iam::123456789012:role/ebs\nweb_identity_token_file = " + csioperatorclient.ShortTermTokenPath + "\n"),
+			expectToken:       true,
+			expectedCondition: operatorv1.ConditionTrue,
+		},
+		{
+			name:              "STS with role in another profile",
+			secret:            credentialsSecret("[default]\nweb_identity_token_file = " + csioperatorclient.ShortTermTokenPath + "\n[profile ebs]\nrole_arn = arn:aws:iam::123456789012:role/ebs\n"),
+			expectToken:       true,
+			expectedCondition: operatorv1.ConditionTrue,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clients, err := syncCredentialsTestController(t, test.secret)
+			assert.NoError(t, err)
+
+			_, opStatus, _, err := clients.OperatorClient.GetOperatorState()
+			assert.NoError(t, err)
+			cond := v1helpers.FindOperatorCondition(opStatus.Conditions, "AWSEBSShortTermCredentialsDegraded")
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, test.expectedCondition, cond.Status)
+				if test.expectedCondition == operatorv1.ConditionTrue {
+					assert.Equal(t, missingCloudIdentityReason, cond.Reason)
+					assert.Contains(t, cond.Message, "role_arn")
+				}
+			}
+
+			deployment, err := clients.KubeClient.AppsV1().Deployments(csoclients.CSIOperatorNamespace).Get(context.TODO(), "aws-ebs-csi-driver-operator", metav1.GetOptions{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			podSpec := deployment.Spec.Template.Spec
+			hasVolume := false
+			for _, volume := range podSpec.Volumes {
+				if volume.Name == shortTermTokenVolumeName {
+					hasVolume = true
+					assert.Equal(t, "openshift", volume.Projected.Sources[0].ServiceAccountToken.Audience)
+				}
+			}
+			assert.Equal(t, test.expectToken, hasVolume)
+			assert.Equal(t, test.expectToken, mountsPath(podSpec.Containers[0], csioperatorclient.ShortTermTokenDir))
+			hasEnv := false
+			for _, env := range podSpec.Containers[0].Env {
+				if env.Name == "AWS_WEB_IDENTITY_TOKEN_FILE" {
+					hasEnv = true
+					assert.Equal(t, csioperatorclient.ShortTermTokenPath, env.Value)
+				}
+			}
+			assert.Equal(t, test.expectToken, hasEnv)
+		})
+	}
+}
@@ -70,9 +70,10 @@ type standAloneDriverStarter struct {
 
 type hypershiftDriverStarter struct {
 	driverStarterCommon
-	mgmtClient          *csoclients.Clients
-	mgmtEventRecorder   events.Recorder
-	controllerNamespace string
+	mgmtClient             *csoclients.Clients
+	mgmtEventRecorder      events.Recorder
+	controllerNamespace    string
+	hostedControlPlaneName string
 }
 
 type RelatedObjectGetter interface {
@@ -306,6 +307,15 @@ func (s *standAloneDriverStarter) addExtraControllersToManager(manager manager.C
 		).WithIgnoreNotFoundOnCreate(), 1)
 	}
 
+	if cfg.PrometheusRuleAsset != "" {
+		manager = manager.WithController(NewPrometheusRuleController(
+			s.commonClients,
+			cfg,
+			s.resyncInterval,
+			s.eventRecorder,
+		), 1)
+	}
+
 	for i := range cfg.ExtraControllers {
 		manager = manager.WithController(cfg.ExtraControllers[i], 1)
 	}
@@ -316,6 +326,7 @@ func NewHypershiftDriverStarter(
 	mgmtClients *csoclients.Clients,
 	fg featuregates.FeatureGate,
 	controlNamespace string,
+	hostedControlPlaneName string,
 	resyncInterval time.Duration,
 	versionGetter status.VersionGetter,
 	targetVersion string,
@@ -328,6 +339,7 @@ func NewHypershiftDriverStarter(
 		mgmtClients,
 		mgmtEventRecorder,
 		controlNamespace,
+		hostedControlPlaneName,
 	}
 
 	return c.initController(driverConfigs, c), c
@@ -337,9 +349,21 @@ func (h *hypershiftDriverStarter) addExtraControllersToManager(manager manager.C
 	mgmtStaticResourceClient := resourceapply.NewKubeClientHolder(h.mgmtClient.KubeClient).WithDynamicClient(h.mgmtClient.DynamicClient)
 	namespacedAssetFunc := namespaceReplacer(assets.ReadFile, "${CONTROLPLANE_NAMESPACE}", h.controllerNamespace)
 
+	// Stop applying the assets once the Storage CR is being deleted, HyperShiftTeardownController removes them.
+	shouldCreate := func() bool {
+		deleting, err := isOperatorBeingDeleted(h.commonClients.OperatorClient)
+		if err != nil {
+			klog.Warningf("Failed to get Storage CR metadata: %v", err)
+			return false
+		}
+		return !deleting
+	}
+	shouldDelete := func() bool { return false }
+
 	mgmtStaticResourceController := staticresourcecontroller.NewStaticResourceController(
 		cfg.ConditionPrefix+"CSIDriverOperatorMgmtStaticController",
-		namespacedAssetFunc, cfg.MgmtStaticAssets, mgmtStaticResourceClient, h.commonClients.OperatorClient, h.eventRecorder).
+		namespacedAssetFunc, nil, mgmtStaticResourceClient, h.commonClients.OperatorClient, h.eventRecorder).
+		WithConditionalResources(namespacedAssetFunc, cfg.MgmtStaticAssets, shouldCreate, shouldDelete).
 		AddKubeInformers(h.mgmtClient.KubeInformers).
 		AddRESTMapper(h.mgmtClient.RestMapper).
 		AddCategoryExpander(h.mgmtClient.CategoryExpander)
@@ -361,6 +385,7 @@ func (h *hypershiftDriverStarter) addExtraControllersToManager(manager manager.C
 		h.mgmtClient,
 		h.commonClients,
 		h.controllerNamespace,
+		h.hostedControlPlaneName,
 		cfg,
 		h.versionGetter,
 		h.targetVersion,
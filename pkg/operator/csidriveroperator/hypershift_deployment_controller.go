@@ -35,7 +35,7 @@ var _ factory.Controller = &HyperShiftDeploymentController{}
 var (
 	envHyperShiftImage = os.Getenv("HYPERSHIFT_IMAGE")
 
-	hostedControlPlaneGVR = schema.GroupVersionResource{
+	HostedControlPlaneGVR = schema.GroupVersionResource{
 		Group:    "hypershift.openshift.io",
 		Version:  "v1beta1",
 		Resource: "hostedcontrolplanes",
@@ -56,6 +56,7 @@ type HyperShiftDeploymentController struct {
 	CommonCSIDeploymentController
 	mgmtClient               *csoclients.Clients
 	controlNamespace         string
+	hostedControlPlaneName   string
 	hostedControlPlaneLister cache.GenericLister
 }
 
@@ -63,13 +64,14 @@ func NewHyperShiftControllerDeployment(
 	mgtClient *csoclients.Clients,
 	guestClient *csoclients.Clients,
 	controlNamespace string,
+	hostedControlPlaneName string,
 	csiOperatorConfig csioperatorclient.CSIOperatorConfig,
 	versionGetter status.VersionGetter,
 	targetVersion string,
 	eventRecorder events.Recorder,
 	resyncInterval time.Duration,
 ) factory.Controller {
-	hostedControlPlaneInformer := mgtClient.DynamicInformer.ForResource(hostedControlPlaneGVR)
+	hostedControlPlaneInformer := mgtClient.DynamicInformer.ForResource(HostedControlPlaneGVR)
 	c := &HyperShiftDeploymentController{
 		CommonCSIDeploymentController: initCommonDeploymentParams(
 			guestClient,
@@ -81,6 +83,7 @@ func NewHyperShiftControllerDeployment(
 		),
 		mgmtClient:               mgtClient,
 		controlNamespace:         controlNamespace,
+		hostedControlPlaneName:   hostedControlPlaneName,
 		hostedControlPlaneLister: hostedControlPlaneInformer.Lister(),
 	}
 	f := c.initController(func(f *factory.Factory) {
@@ -106,6 +109,15 @@ func (c *HyperShiftDeploymentController) Sync(ctx context.Context, syncCtx facto
 		return nil
 	}
 
+	// Do not re-create the Deployment removed by HyperShiftTeardownController.
+	deleting, err := isOperatorBeingDeleted(c.operatorClient)
+	if err != nil {
+		return err
+	}
+	if deleting {
+		return nil
+	}
+
 	replacers := []*strings.Replacer{sidecarReplacer}
 	// Replace images
 	if c.csiOperatorConfig.ImageReplacer != nil {
@@ -187,10 +199,28 @@ func (c *HyperShiftDeploymentController) Sync(ctx context.Context, syncCtx facto
 		return err
 	}
 
+	// Harden the pod for management clusters without SCCs
+	hcp, err := c.getHostedControlPlane()
+	if err != nil {
+		return err
+	}
+	profile, err := podSecurityProfileFromHCP(hcp)
+	if err != nil {
+		return err
+	}
+	err = applyPodSecurityProfile(&requiredCopy.Spec.Template.Spec, profile)
+	if err != nil {
+		return err
+	}
+
+	// Restart the pods when the TLS profile in the operator config changes
+	var operatorConfigHash string
 	if c.csiOperatorConfig.MgmtOperatorConfigAsset != "" {
-		if err := c.reconcileOperatorConfigMap(ctx); err != nil {
+		operatorConfigHash, err = c.reconcileOperatorConfigMap(ctx)
+		if err != nil {
 			return err
 		}
+		csotls.SetOperatorConfigHash(requiredCopy, operatorConfigHash)
 	}
 
 	lastGeneration := resourcemerge.ExpectedDeploymentGeneration(requiredCopy, opStatus.Generations)
@@ -198,7 +228,7 @@ func (c *HyperShiftDeploymentController) Sync(ctx context.Context, syncCtx facto
 	if err != nil {
 		return err
 	}
-	err = c.postSync(ctx, deployment)
+	err = c.postSync(ctx, deployment, operatorConfigHash)
 	if err != nil {
 		return err
 	}
@@ -208,10 +238,11 @@ func (c *HyperShiftDeploymentController) Sync(ctx context.Context, syncCtx facto
 
 // reconcileOperatorConfigMap reads the mgmt ConfigMap asset for name/namespace, builds a
 // typed GenericOperatorConfig with TLS settings from the HostedControlPlane, and applies it.
-func (c *HyperShiftDeploymentController) reconcileOperatorConfigMap(ctx context.Context) error {
+// It returns hash of the applied ConfigMap.
+func (c *HyperShiftDeploymentController) reconcileOperatorConfigMap(ctx context.Context) (string, error) {
 	assetBytes, err := assets.ReadFile(c.csiOperatorConfig.MgmtOperatorConfigAsset)
 	if err != nil {
-		return fmt.Errorf("failed to read operator config asset: %w", err)
+		return "", fmt.Errorf("failed to read operator config asset: %w", err)
 	}
 
 	nsReplacer := strings.NewReplacer("${CONTROLPLANE_NAMESPACE}", c.controlNamespace)
@@ -219,38 +250,47 @@ func (c *HyperShiftDeploymentController) reconcileOperatorConfigMap(ctx context.
 
 	cm := &corev1.ConfigMap{}
 	if err := sigsyaml.Unmarshal([]byte(assetContent), cm); err != nil {
-		return fmt.Errorf("failed to decode operator config ConfigMap: %w", err)
+		return "", fmt.Errorf("failed to decode operator config ConfigMap: %w", err)
 	}
 
-	minTLSVersion, cipherSuites, err := c.getHostedControlPlaneTLSSettings()
+	tlsSettings, err := c.getHostedControlPlaneTLSSettings()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	yaml, err := csotls.OperatorConfigYAML(minTLSVersion, cipherSuites)
+	yaml, err := csotls.OperatorConfigYAML(tlsSettings)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if cm.Data == nil {
 		cm.Data = make(map[string]string)
 	}
 	cm.Data["config.yaml"] = yaml
 
-	_, _, err = resourceapply.ApplyConfigMap(ctx, c.mgmtClient.KubeClient.CoreV1(), c.eventRecorder, cm)
-	return err
+	applied, _, err := resourceapply.ApplyConfigMap(ctx, c.mgmtClient.KubeClient.CoreV1(), c.eventRecorder, cm)
+	if err != nil {
+		return "", err
+	}
+	return csotls.OperatorConfigHash(applied)
 }
 
-func (c *HyperShiftDeploymentController) getHostedControlPlaneTLSSettings() (string, []string, error) {
-	hcp, err := c.getHostedControlPlane()
+func (c *HyperShiftDeploymentController) getHostedControlPlaneTLSSettings() (csotls.TLSSettings, error) {
+	return HostedControlPlaneTLSSettings(c.hostedControlPlaneLister, c.controlNamespace, c.hostedControlPlaneName)
+}
+
+// HostedControlPlaneTLSSettings returns TLS settings of the APIServer TLS security profile
+// of the HostedControlPlane, see getHostedControlPlane for how it is found.
+func HostedControlPlaneTLSSettings(lister cache.GenericLister, namespace, name string) (csotls.TLSSettings, error) {
+	hcp, err := getHostedControlPlane(lister, namespace, name)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get HostedControlPlane: %w", err)
+		return csotls.TLSSettings{}, fmt.Errorf("failed to get HostedControlPlane: %w", err)
 	}
 	return tlsSettingsFromHCP(hcp)
 }
 
 // tlsSettingsFromHCP extracts the TLS security profile from an HCP unstructured object
-// and returns the minTLSVersion and IANA cipher suite names.
-func tlsSettingsFromHCP(hcp *unstructured.Unstructured) (string, []string, error) {
+// and returns its TLS settings.
+func tlsSettingsFromHCP(hcp *unstructured.Unstructured) (csotls.TLSSettings, error) {
 	profileType, _, err := unstructured.NestedString(hcp.UnstructuredContent(), "spec", "configuration", "apiServer", "tlsSecurityProfile", "type")
 	if err != nil {
 		klog.Warningf("Failed to get HCP TLS profile type: %v", err)
@@ -276,8 +316,7 @@ func tlsSettingsFromHCP(hcp *unstructured.Unstructured) (string, []string, error
 		}
 	}
 
-	minTLSVersion, cipherSuites := csotls.TLSSettingsFromProfile(profile)
-	return minTLSVersion, cipherSuites, nil
+	return csotls.TLSSettingsFromProfile(profile), nil
 }
 
 func (c *HyperShiftDeploymentController) Run(ctx context.Context, workers int) {
@@ -386,20 +425,38 @@ func (c *HyperShiftDeploymentController) getHostedControlPlaneLabels() (map[stri
 }
 
 func (c *HyperShiftDeploymentController) getHostedControlPlane() (*unstructured.Unstructured, error) {
-	list, err := c.hostedControlPlaneLister.ByNamespace(c.controlNamespace).List(labels.Everything())
+	return getHostedControlPlane(c.hostedControlPlaneLister, c.controlNamespace, c.hostedControlPlaneName)
+}
+
+// getHostedControlPlane returns the HostedControlPlane named by --hosted-control-plane-name.
+// Without the name, it returns the only HostedControlPlane in the control plane namespace.
+func getHostedControlPlane(lister cache.GenericLister, namespace, name string) (*unstructured.Unstructured, error) {
+	if name != "" {
+		obj, err := lister.ByNamespace(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		hcp, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unknown type of HostedControlPlane %s/%s", namespace, name)
+		}
+		return hcp, nil
+	}
+
+	list, err := lister.ByNamespace(namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
 	if len(list) == 0 {
-		return nil, fmt.Errorf("no HostedControlPlane found in namespace %s", c.controlNamespace)
+		return nil, fmt.Errorf("no HostedControlPlane found in namespace %s", namespace)
 	}
 	if len(list) > 1 {
-		return nil, fmt.Errorf("more than one HostedControlPlane found in namespace %s", c.controlNamespace)
+		return nil, fmt.Errorf("more than one HostedControlPlane found in namespace %s, use --hosted-control-plane-name to select one", namespace)
 	}
 
 	hcp := list[0].(*unstructured.Unstructured)
 	if hcp == nil {
-		return nil, fmt.Errorf("unknown type of HostedControlPlane found in namespace %s", c.controlNamespace)
+		return nil, fmt.Errorf("unknown type of HostedControlPlane found in namespace %s", namespace)
 	}
 	return hcp, nil
 }
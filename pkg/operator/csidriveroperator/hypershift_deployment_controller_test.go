@@ -7,6 +7,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
 )
 
 func tlsProfileHCP(profileType string, extras map[string]any) *unstructured.Unstructured {
@@ -91,10 +92,84 @@ func TestTLSSettingsFromHCP(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotVersion, gotCiphers, err := tlsSettingsFromHCP(tt.hcp)
+			got, err := tlsSettingsFromHCP(tt.hcp)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.wantMinVersion, gotVersion)
-			assert.Equal(t, tt.wantCiphers, gotCiphers)
+			assert.Equal(t, tt.wantMinVersion, got.MinTLSVersion)
+			assert.Equal(t, tt.wantCiphers, got.CipherSuites)
+		})
+	}
+}
+
+func namedHCP(namespace, name string) *unstructured.Unstructured {
+	hcp := &unstructured.Unstructured{Object: map[string]any{}}
+	hcp.SetNamespace(namespace)
+	hcp.SetName(name)
+	return hcp
+}
+
+func TestGetHostedControlPlane(t *testing.T) {
+	const namespace = "clusters-test"
+
+	tests := []struct {
+		name      string
+		hcps      []*unstructured.Unstructured
+		hcpName   string
+		wantName  string
+		expectErr bool
+	}{
+		{
+			name:     "single HCP is auto-detected",
+			hcps:     []*unstructured.Unstructured{namedHCP(namespace, "hcp1")},
+			wantName: "hcp1",
+		},
+		{
+			name:      "no HCP",
+			expectErr: true,
+		},
+		{
+			name:      "multiple HCPs without name",
+			hcps:      []*unstructured.Unstructured{namedHCP(namespace, "hcp1"), namedHCP(namespace, "hcp2")},
+			expectErr: true,
+		},
+		{
+			name:     "multiple HCPs with name",
+			hcps:     []*unstructured.Unstructured{namedHCP(namespace, "hcp1"), namedHCP(namespace, "hcp2")},
+			hcpName:  "hcp2",
+			wantName: "hcp2",
+		},
+		{
+			name:      "named HCP does not exist",
+			hcps:      []*unstructured.Unstructured{namedHCP(namespace, "hcp1")},
+			hcpName:   "hcp2",
+			expectErr: true,
+		},
+		{
+			name:      "named HCP in another namespace",
+			hcps:      []*unstructured.Unstructured{namedHCP("other", "hcp1")},
+			hcpName:   "hcp1",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, hcp := range tt.hcps {
+				assert.NoError(t, indexer.Add(hcp))
+			}
+			c := &HyperShiftDeploymentController{
+				controlNamespace:         namespace,
+				hostedControlPlaneName:   tt.hcpName,
+				hostedControlPlaneLister: cache.NewGenericLister(indexer, HostedControlPlaneGVR.GroupResource()),
+			}
+
+			hcp, err := c.getHostedControlPlane()
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantName, hcp.GetName())
 		})
 	}
 }
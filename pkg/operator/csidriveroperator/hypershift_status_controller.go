@@ -0,0 +1,347 @@
+package csidriveroperator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	opclient "github.com/openshift/client-go/operator/clientset/versioned"
+	oplisters "github.com/openshift/client-go/operator/listers/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// StorageDriversAvailableCondition is the HostedControlPlane condition that summarizes
+	// health of all CSI drivers of the hosted cluster.
+	StorageDriversAvailableCondition = "StorageDriversAvailable"
+	// DriverHealthyCondition is the ClusterCSIDriver condition with health of the driver.
+	// It does not end with "Available", so CSIDriverOperatorCRController does not union it
+	// into the Storage conditions, which already report the driver operator state.
+	DriverHealthyCondition = "HyperShiftDriverHealthy"
+	// HostedControlPlaneStatusCondition is the informational Storage condition that reports
+	// whether CSO can publish StorageDriversAvailableCondition on the HostedControlPlane.
+	HostedControlPlaneStatusCondition = "HostedControlPlaneStatusReported"
+
+	// hyperShiftStatusFieldManager owns StorageDriversAvailableCondition in HostedControlPlane status.
+	hyperShiftStatusFieldManager   = "cluster-storage-operator"
+	hyperShiftStatusControllerName = "HyperShiftStatusController"
+)
+
+// HyperShiftStatusController reports health of the CSI driver operator Deployment in the
+// management cluster and the CSI driver node DaemonSets in the guest cluster of each installed
+// CSI driver. Each driver gets DriverHealthyCondition on its guest ClusterCSIDriver and the
+// summary is published as StorageDriversAvailableCondition on the HostedControlPlane, so
+// management cluster admins can see broken storage of a hosted cluster without guest credentials.
+//
+// HostedControlPlane status is written by control-plane-operator. CSO server-side applies only
+// its own condition with hyperShiftStatusFieldManager, without forcing, so it owns just that list
+// entry and never takes over fields of other managers. It needs "patch" of hostedcontrolplanes/status
+// in the Role HyperShift creates for CSO in the control plane namespace. Until HyperShift grants it,
+// HostedControlPlaneStatusCondition is False on the Storage CR and only ClusterCSIDrivers are updated.
+type HyperShiftStatusController struct {
+	operatorClient           v1helpers.OperatorClient
+	operatorClientSet        opclient.Interface
+	clusterCSIDriverLister   oplisters.ClusterCSIDriverLister
+	daemonSetLister          appslisters.DaemonSetNamespaceLister
+	deploymentLister         appslisters.DeploymentNamespaceLister
+	hostedControlPlaneLister cache.GenericLister
+	mgmtDynamicClient        dynamic.Interface
+	controlNamespace         string
+	hostedControlPlaneName   string
+	csiDriverConfigs         []csioperatorclient.CSIOperatorConfig
+}
+
+func NewHyperShiftStatusController(
+	clients *csoclients.Clients,
+	mgmtClient *csoclients.Clients,
+	controlNamespace string,
+	hostedControlPlaneName string,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+
+	clusterCSIDriverInformer := clients.OperatorInformers.Operator().V1().ClusterCSIDrivers()
+	daemonSetInformer := clients.KubeInformers.InformersFor(csoclients.CSIOperatorNamespace).Apps().V1().DaemonSets()
+	deploymentInformer := mgmtClient.KubeInformers.InformersFor(controlNamespace).Apps().V1().Deployments()
+	hostedControlPlaneInformer := mgmtClient.DynamicInformer.ForResource(HostedControlPlaneGVR)
+
+	c := &HyperShiftStatusController{
+		operatorClient:           clients.OperatorClient,
+		operatorClientSet:        clients.OperatorClientSet,
+		clusterCSIDriverLister:   clusterCSIDriverInformer.Lister(),
+		daemonSetLister:          daemonSetInformer.Lister().DaemonSets(csoclients.CSIOperatorNamespace),
+		deploymentLister:         deploymentInformer.Lister().Deployments(controlNamespace),
+		hostedControlPlaneLister: hostedControlPlaneInformer.Lister(),
+		mgmtDynamicClient:        mgmtClient.DynamicClient,
+		controlNamespace:         controlNamespace,
+		hostedControlPlaneName:   hostedControlPlaneName,
+		csiDriverConfigs:         csiDriverConfigs,
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			clients.OperatorClient.Informer(),
+			clusterCSIDriverInformer.Informer(),
+			daemonSetInformer.Informer(),
+			deploymentInformer.Informer(),
+			hostedControlPlaneInformer.Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(hyperShiftStatusControllerName, eventRecorder)
+}
+
+func (c *HyperShiftStatusController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("HyperShiftStatusController sync started")
+	defer klog.V(4).Infof("HyperShiftStatusController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	var errs []error
+	var problems []string
+	for _, cfg := range c.csiDriverConfigs {
+		ccd, err := c.clusterCSIDriverLister.Get(cfg.CSIDriverName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ccd.Spec.ManagementState == operatorapi.Removed {
+			continue
+		}
+
+		driverProblems, err := c.driverProblems(cfg)
+		if err != nil {
+			return err
+		}
+		if err := c.setClusterCSIDriverCondition(ctx, ccd, driverProblems); err != nil {
+			errs = append(errs, err)
+		}
+		for _, problem := range driverProblems {
+			problems = append(problems, fmt.Sprintf("%s: %s", cfg.ConditionPrefix, problem))
+		}
+	}
+
+	hcp, err := getHostedControlPlane(c.hostedControlPlaneLister, c.controlNamespace, c.hostedControlPlaneName)
+	if err != nil {
+		return err
+	}
+	err = c.applyHostedControlPlaneCondition(ctx, hcp, driversAvailableCondition(problems))
+	if err != nil && !apierrors.IsForbidden(err) {
+		errs = append(errs, err)
+		return errors.NewAggregate(errs)
+	}
+	hcpStatusCnd := operatorapi.OperatorCondition{
+		Type:   HostedControlPlaneStatusCondition,
+		Status: operatorapi.ConditionTrue,
+		Reason: "AsExpected",
+	}
+	if err != nil {
+		hcpStatusCnd.Status = operatorapi.ConditionFalse
+		hcpStatusCnd.Reason = "Forbidden"
+		hcpStatusCnd.Message = fmt.Sprintf("Condition %s is not reported: %v", StorageDriversAvailableCondition, err)
+	}
+	if _, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(hcpStatusCnd)); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.NewAggregate(errs)
+}
+
+// driverProblems checks health of the driver operator Deployment and the node DaemonSets of a CSI driver.
+func (c *HyperShiftStatusController) driverProblems(cfg csioperatorclient.CSIOperatorConfig) ([]string, error) {
+	var problems []string
+	deploymentProblem, err := c.deploymentProblem(cfg.CSIDriverDeploymentName)
+	if err != nil {
+		return nil, err
+	}
+	if deploymentProblem != "" {
+		problems = append(problems, deploymentProblem)
+	}
+	for _, name := range cfg.NodeDaemonSetNames {
+		daemonSetProblem, err := c.daemonSetProblem(name)
+		if err != nil {
+			return nil, err
+		}
+		if daemonSetProblem != "" {
+			problems = append(problems, daemonSetProblem)
+		}
+	}
+	return problems, nil
+}
+
+// driversAvailableCondition summarizes problems of all CSI drivers that have a ClusterCSIDriver
+// in the guest cluster.
+func driversAvailableCondition(problems []string) metav1.Condition {
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return metav1.Condition{
+			Type:    StorageDriversAvailableCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "DriversUnavailable",
+			Message: strings.Join(problems, "\n"),
+		}
+	}
+	return metav1.Condition{
+		Type:    StorageDriversAvailableCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AsExpected",
+		Message: "All storage drivers are available",
+	}
+}
+
+func (c *HyperShiftStatusController) deploymentProblem(name string) (string, error) {
+	deployment, err := c.deploymentLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("Deployment %s/%s not found", c.controlNamespace, name), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return deploymentAvailabilityProblem(deployment), nil
+}
+
+func (c *HyperShiftStatusController) daemonSetProblem(name string) (string, error) {
+	daemonSet, err := c.daemonSetLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("DaemonSet %s/%s not found", csoclients.CSIOperatorNamespace, name), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return daemonSetAvailabilityProblem(daemonSet), nil
+}
+
+func deploymentAvailabilityProblem(deployment *appsv1.Deployment) string {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.AvailableReplicas < replicas {
+		return fmt.Sprintf("Deployment %s/%s has %d/%d available replicas",
+			deployment.Namespace, deployment.Name, deployment.Status.AvailableReplicas, replicas)
+	}
+	return ""
+}
+
+func daemonSetAvailabilityProblem(daemonSet *appsv1.DaemonSet) string {
+	if daemonSet.Status.NumberAvailable < daemonSet.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("DaemonSet %s/%s has %d/%d available pods",
+			daemonSet.Namespace, daemonSet.Name, daemonSet.Status.NumberAvailable, daemonSet.Status.DesiredNumberScheduled)
+	}
+	return ""
+}
+
+// setClusterCSIDriverCondition sets DriverHealthyCondition in the ClusterCSIDriver status,
+// leaving conditions of the CSI driver operator untouched.
+func (c *HyperShiftStatusController) setClusterCSIDriverCondition(ctx context.Context, ccd *operatorapi.ClusterCSIDriver, problems []string) error {
+	condition := operatorapi.OperatorCondition{
+		Type:   DriverHealthyCondition,
+		Status: operatorapi.ConditionTrue,
+		Reason: "AsExpected",
+	}
+	if len(problems) > 0 {
+		condition.Status = operatorapi.ConditionFalse
+		condition.Reason = "DriverUnavailable"
+		condition.Message = strings.Join(problems, "\n")
+	}
+	if existing := v1helpers.FindOperatorCondition(ccd.Status.Conditions, condition.Type); existing != nil &&
+		existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		existing, err := c.operatorClientSet.OperatorV1().ClusterCSIDrivers().Get(ctx, ccd.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updated := existing.DeepCopy()
+		v1helpers.SetOperatorCondition(&updated.Status.Conditions, condition)
+		_, err = c.operatorClientSet.OperatorV1().ClusterCSIDrivers().UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update ClusterCSIDriver %s status: %w", ccd.Name, err)
+	}
+	klog.V(2).Infof("ClusterCSIDriver %s condition %s set to %s: %s", ccd.Name, condition.Type, condition.Status, condition.Message)
+	return nil
+}
+
+// applyHostedControlPlaneCondition server-side applies the condition to HostedControlPlane
+// status.conditions. Conditions of other components are not part of the applied object, so
+// they are left untouched.
+func (c *HyperShiftStatusController) applyHostedControlPlaneCondition(ctx context.Context, hcp *unstructured.Unstructured, condition metav1.Condition) error {
+	rawConditions, _, err := unstructured.NestedSlice(hcp.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	conditions := make([]metav1.Condition, 0, len(rawConditions))
+	for _, raw := range rawConditions {
+		rawMap, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var cond metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawMap, &cond); err != nil {
+			return fmt.Errorf("failed to decode HostedControlPlane condition: %w", err)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	condition.ObservedGeneration = hcp.GetGeneration()
+	condition.LastTransitionTime = metav1.Now()
+	if existing := meta.FindStatusCondition(conditions, condition.Type); existing != nil && existing.Status == condition.Status {
+		if existing.Reason == condition.Reason && existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+			return nil
+		}
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+
+	rawCondition, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&condition)
+	if err != nil {
+		return err
+	}
+	applyConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{rawCondition},
+		},
+	}}
+	applyConfig.SetAPIVersion(hcp.GetAPIVersion())
+	applyConfig.SetKind(hcp.GetKind())
+	applyConfig.SetNamespace(hcp.GetNamespace())
+	applyConfig.SetName(hcp.GetName())
+
+	_, err = c.mgmtDynamicClient.Resource(HostedControlPlaneGVR).Namespace(hcp.GetNamespace()).ApplyStatus(ctx, hcp.GetName(), applyConfig, metav1.ApplyOptions{
+		FieldManager: hyperShiftStatusFieldManager,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply HostedControlPlane %s/%s status: %w", hcp.GetNamespace(), hcp.GetName(), err)
+	}
+	klog.V(2).Infof("HostedControlPlane %s/%s condition %s set to %s: %s", hcp.GetNamespace(), hcp.GetName(), condition.Type, condition.Status, condition.Message)
+	return nil
+}
@@ -0,0 +1,294 @@
+package csidriveroperator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	fakeop "github.com/openshift/client-go/operator/clientset/versioned/fake"
+	oplisters "github.com/openshift/client-go/operator/listers/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+const statusTestNamespace = "clusters-test"
+
+func statusTestHCP() *unstructured.Unstructured {
+	hcp := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{
+					"type":               "Available",
+					"status":             "True",
+					"reason":             "AsExpected",
+					"message":            "",
+					"lastTransitionTime": "2024-01-01T00:00:00Z",
+				},
+			},
+		},
+	}}
+	hcp.SetAPIVersion("hypershift.openshift.io/v1beta1")
+	hcp.SetKind("HostedControlPlane")
+	hcp.SetNamespace(statusTestNamespace)
+	hcp.SetName("hcp")
+	hcp.SetGeneration(3)
+	return hcp
+}
+
+func statusTestDeployment(name string, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: statusTestNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](1)},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+	}
+}
+
+func statusTestDaemonSet(name string, desired, available int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: csoclients.CSIOperatorNamespace},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: desired, NumberAvailable: available},
+	}
+}
+
+func statusTestClusterCSIDriver(name string, state opv1.ManagementState) *opv1.ClusterCSIDriver {
+	return &opv1.ClusterCSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: opv1.ClusterCSIDriverSpec{
+			OperatorSpec: opv1.OperatorSpec{ManagementState: state},
+		},
+	}
+}
+
+func TestHyperShiftStatusController(t *testing.T) {
+	cfg := csioperatorclient.CSIOperatorConfig{
+		CSIDriverName:           "ebs.csi.aws.com",
+		CSIDriverDeploymentName: "aws-ebs-csi-driver-operator",
+		NodeDaemonSetNames:      []string{"aws-ebs-csi-driver-node"},
+		ConditionPrefix:         "AWSEBS",
+	}
+
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+		// expectedDriverMessage is the message of DriverHealthyCondition on the ClusterCSIDriver
+		expectedDriverMessage string
+	}{
+		{
+			name:            "no driver installed",
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AsExpected",
+			expectedMessage: "All storage drivers are available",
+		},
+		{
+			name: "removed driver is ignored",
+			objects: []runtime.Object{
+				statusTestClusterCSIDriver(cfg.CSIDriverName, opv1.Removed),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AsExpected",
+			expectedMessage: "All storage drivers are available",
+		},
+		{
+			name: "healthy driver",
+			objects: []runtime.Object{
+				statusTestClusterCSIDriver(cfg.CSIDriverName, opv1.Managed),
+				statusTestDeployment(cfg.CSIDriverDeploymentName, 1),
+				statusTestDaemonSet("aws-ebs-csi-driver-node", 3, 3),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "AsExpected",
+			expectedMessage: "All storage drivers are available",
+		},
+		{
+			name: "unavailable Deployment and DaemonSet",
+			objects: []runtime.Object{
+				statusTestClusterCSIDriver(cfg.CSIDriverName, opv1.Managed),
+				statusTestDeployment(cfg.CSIDriverDeploymentName, 0),
+				statusTestDaemonSet("aws-ebs-csi-driver-node", 3, 2),
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DriversUnavailable",
+			expectedMessage: "AWSEBS: DaemonSet openshift-cluster-csi-drivers/aws-ebs-csi-driver-node has 2/3 available pods\n" +
+				"AWSEBS: Deployment clusters-test/aws-ebs-csi-driver-operator has 0/1 available replicas",
+			expectedDriverMessage: "Deployment clusters-test/aws-ebs-csi-driver-operator has 0/1 available replicas\n" +
+				"DaemonSet openshift-cluster-csi-drivers/aws-ebs-csi-driver-node has 2/3 available pods",
+		},
+		{
+			name: "missing Deployment and DaemonSet",
+			objects: []runtime.Object{
+				statusTestClusterCSIDriver(cfg.CSIDriverName, opv1.Managed),
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DriversUnavailable",
+			expectedMessage: "AWSEBS: DaemonSet openshift-cluster-csi-drivers/aws-ebs-csi-driver-node not found\n" +
+				"AWSEBS: Deployment clusters-test/aws-ebs-csi-driver-operator not found",
+			expectedDriverMessage: "Deployment clusters-test/aws-ebs-csi-driver-operator not found\n" +
+				"DaemonSet openshift-cluster-csi-drivers/aws-ebs-csi-driver-node not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := func() cache.Indexer {
+				return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			}
+			ccdIndexer, dsIndexer, deployIndexer, hcpIndexer := indexer(), indexer(), indexer(), indexer()
+			var operatorObjects []runtime.Object
+			for _, obj := range tt.objects {
+				switch obj.(type) {
+				case *opv1.ClusterCSIDriver:
+					assert.NoError(t, ccdIndexer.Add(obj))
+					operatorObjects = append(operatorObjects, obj)
+				case *appsv1.DaemonSet:
+					assert.NoError(t, dsIndexer.Add(obj))
+				case *appsv1.Deployment:
+					assert.NoError(t, deployIndexer.Add(obj))
+				}
+			}
+			hcp := statusTestHCP()
+			assert.NoError(t, hcpIndexer.Add(hcp))
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{HostedControlPlaneGVR: "HostedControlPlaneList"}, hcp.DeepCopy())
+			// The fake object tracker does not implement server-side apply of unstructured objects
+			dynamicClient.PrependReactor("patch", "hostedcontrolplanes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, hcp, nil
+			})
+			operatorClientSet := fakeop.NewSimpleClientset(operatorObjects...)
+
+			cr := csoclients.GetCR()
+			operatorClient := v1helpers.NewFakeOperatorClient(&cr.Spec.OperatorSpec, &cr.Status.OperatorStatus, nil)
+			c := &HyperShiftStatusController{
+				operatorClient:           operatorClient,
+				operatorClientSet:        operatorClientSet,
+				clusterCSIDriverLister:   oplisters.NewClusterCSIDriverLister(ccdIndexer),
+				daemonSetLister:          appslisters.NewDaemonSetLister(dsIndexer).DaemonSets(csoclients.CSIOperatorNamespace),
+				deploymentLister:         appslisters.NewDeploymentLister(deployIndexer).Deployments(statusTestNamespace),
+				hostedControlPlaneLister: cache.NewGenericLister(hcpIndexer, HostedControlPlaneGVR.GroupResource()),
+				mgmtDynamicClient:        dynamicClient,
+				controlNamespace:         statusTestNamespace,
+				hostedControlPlaneName:   "hcp",
+				csiDriverConfigs:         []csioperatorclient.CSIOperatorConfig{cfg},
+			}
+
+			err := c.sync(context.TODO(), nil)
+			assert.NoError(t, err)
+
+			// Only the condition owned by CSO is applied, conditions of other components are not sent
+			var applied *unstructured.Unstructured
+			for _, action := range dynamicClient.Actions() {
+				patch, ok := action.(clienttesting.PatchAction)
+				if !ok || patch.GetPatchType() != types.ApplyPatchType || patch.GetSubresource() != "status" {
+					continue
+				}
+				applied = &unstructured.Unstructured{}
+				assert.NoError(t, json.Unmarshal(patch.GetPatch(), &applied.Object))
+			}
+			if !assert.NotNil(t, applied, "HostedControlPlane status was not applied") {
+				return
+			}
+			rawConditions, _, err := unstructured.NestedSlice(applied.Object, "status", "conditions")
+			assert.NoError(t, err)
+			if assert.Len(t, rawConditions, 1) {
+				var cond metav1.Condition
+				assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(rawConditions[0].(map[string]any), &cond))
+				assert.Equal(t, StorageDriversAvailableCondition, cond.Type)
+				assert.Equal(t, tt.expectedStatus, cond.Status)
+				assert.Equal(t, tt.expectedReason, cond.Reason)
+				assert.Equal(t, tt.expectedMessage, cond.Message)
+				assert.Equal(t, int64(3), cond.ObservedGeneration)
+			}
+			_, status, _, err := operatorClient.GetOperatorState()
+			assert.NoError(t, err)
+			if hcpStatusCond := v1helpers.FindOperatorCondition(status.Conditions, HostedControlPlaneStatusCondition); assert.NotNil(t, hcpStatusCond) {
+				assert.Equal(t, opv1.ConditionTrue, hcpStatusCond.Status)
+			}
+
+			ccd, err := operatorClientSet.OperatorV1().ClusterCSIDrivers().Get(context.TODO(), cfg.CSIDriverName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return
+			}
+			assert.NoError(t, err)
+			driverCond := v1helpers.FindOperatorCondition(ccd.Status.Conditions, DriverHealthyCondition)
+			if ccd.Spec.ManagementState == opv1.Removed {
+				assert.Nil(t, driverCond)
+				return
+			}
+			if assert.NotNil(t, driverCond) {
+				assert.Equal(t, opv1.ConditionStatus(tt.expectedStatus), driverCond.Status)
+				assert.Equal(t, tt.expectedDriverMessage, driverCond.Message)
+			}
+		})
+	}
+}
+
+func TestHyperShiftStatusControllerUnchangedCondition(t *testing.T) {
+	hcp := statusTestHCP()
+	conditions, _, _ := unstructured.NestedSlice(hcp.Object, "status", "conditions")
+	conditions = append(conditions, map[string]any{
+		"type":               StorageDriversAvailableCondition,
+		"status":             "True",
+		"reason":             "AsExpected",
+		"message":            "All storage drivers are available",
+		"observedGeneration": int64(3),
+		"lastTransitionTime": "2024-01-01T00:00:00Z",
+	})
+	assert.NoError(t, unstructured.SetNestedSlice(hcp.Object, conditions, "status", "conditions"))
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{HostedControlPlaneGVR: "HostedControlPlaneList"}, hcp.DeepCopy())
+
+	c := &HyperShiftStatusController{mgmtDynamicClient: dynamicClient}
+	err := c.applyHostedControlPlaneCondition(context.TODO(), hcp, driversAvailableCondition(nil))
+	assert.NoError(t, err)
+	assert.Empty(t, dynamicClient.Actions(), "unchanged condition must not be applied")
+}
+
+func TestHyperShiftStatusControllerForbidden(t *testing.T) {
+	hcp := statusTestHCP()
+	hcpIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, hcpIndexer.Add(hcp))
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{HostedControlPlaneGVR: "HostedControlPlaneList"}, hcp.DeepCopy())
+	dynamicClient.PrependReactor("patch", "hostedcontrolplanes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(HostedControlPlaneGVR.GroupResource(), "hcp", nil)
+	})
+
+	cr := csoclients.GetCR()
+	operatorClient := v1helpers.NewFakeOperatorClient(&cr.Spec.OperatorSpec, &cr.Status.OperatorStatus, nil)
+	c := &HyperShiftStatusController{
+		operatorClient:           operatorClient,
+		clusterCSIDriverLister:   oplisters.NewClusterCSIDriverLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		hostedControlPlaneLister: cache.NewGenericLister(hcpIndexer, HostedControlPlaneGVR.GroupResource()),
+		mgmtDynamicClient:        dynamicClient,
+		controlNamespace:         statusTestNamespace,
+		hostedControlPlaneName:   "hcp",
+		csiDriverConfigs:         []csioperatorclient.CSIOperatorConfig{csioperatorclient.GetAWSEBSCSIOperatorConfig(true)},
+	}
+
+	// Missing RBAC is reported in the Storage status instead of failing the sync
+	err := c.sync(context.TODO(), nil)
+	assert.NoError(t, err)
+	_, status, _, err := operatorClient.GetOperatorState()
+	assert.NoError(t, err)
+	if cond := v1helpers.FindOperatorCondition(status.Conditions, HostedControlPlaneStatusCondition); assert.NotNil(t, cond) {
+		assert.Equal(t, opv1.ConditionFalse, cond.Status)
+		assert.Equal(t, "Forbidden", cond.Reason)
+	}
+}
@@ -0,0 +1,130 @@
+package csidriveroperator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HyperShiftTeardownFinalizer is set on the guest Storage CR so that all objects created
+	// by CSO in the management cluster are removed before the CR is released.
+	HyperShiftTeardownFinalizer = "storage.openshift.io/hypershift-mgmt-cleanup"
+
+	hyperShiftTeardownControllerName = "HyperShiftTeardownController"
+)
+
+// HyperShiftTeardownController adds HyperShiftTeardownFinalizer to the guest Storage CR.
+// When the CR is being deleted, it removes the operator Deployments, operator config
+// ConfigMaps and MgmtStaticAssets of all CSI driver operators from the control-plane
+// namespace, in reverse order of their creation, and then releases the finalizer.
+type HyperShiftTeardownController struct {
+	operatorClient   v1helpers.OperatorClientWithFinalizers
+	mgmtClient       *csoclients.Clients
+	controlNamespace string
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig
+	eventRecorder    events.Recorder
+}
+
+func NewHyperShiftTeardownController(
+	clients *csoclients.Clients,
+	mgmtClient *csoclients.Clients,
+	controlNamespace string,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+
+	c := &HyperShiftTeardownController{
+		operatorClient:   clients.OperatorClient,
+		mgmtClient:       mgmtClient,
+		controlNamespace: controlNamespace,
+		csiDriverConfigs: csiDriverConfigs,
+		eventRecorder:    eventRecorder.WithComponentSuffix("hypershift-teardown"),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(clients.OperatorClient.Informer()).
+		ResyncEvery(resyncInterval).
+		ToController(hyperShiftTeardownControllerName, eventRecorder)
+}
+
+func (c *HyperShiftTeardownController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("HyperShiftTeardownController sync started")
+	defer klog.V(4).Infof("HyperShiftTeardownController sync finished")
+
+	meta, err := c.operatorClient.GetObjectMeta()
+	if err != nil {
+		return err
+	}
+	if meta.DeletionTimestamp == nil {
+		return c.operatorClient.EnsureFinalizer(ctx, HyperShiftTeardownFinalizer)
+	}
+	if !slices.Contains(meta.Finalizers, HyperShiftTeardownFinalizer) {
+		return nil
+	}
+
+	if err := c.deleteMgmtObjects(ctx); err != nil {
+		return err
+	}
+	klog.V(2).Infof("All management cluster objects in namespace %s removed, releasing finalizer %s", c.controlNamespace, HyperShiftTeardownFinalizer)
+	return c.operatorClient.RemoveFinalizer(ctx, HyperShiftTeardownFinalizer)
+}
+
+// deleteMgmtObjects deletes objects of all CSI driver operators from the management cluster.
+// The Deployment goes first, then its config ConfigMap and finally the MgmtStaticAssets
+// (ServiceAccount, Roles, Services, ...) that the Deployment depends on.
+func (c *HyperShiftTeardownController) deleteMgmtObjects(ctx context.Context) error {
+	clientHolder := resourceapply.NewKubeClientHolder(c.mgmtClient.KubeClient).WithDynamicClient(c.mgmtClient.DynamicClient)
+	namespacedAssetFunc := namespaceReplacer(assets.ReadFile, "${CONTROLPLANE_NAMESPACE}", c.controlNamespace)
+
+	var errs []error
+	for i := len(c.csiDriverConfigs) - 1; i >= 0; i-- {
+		files := teardownAssets(c.csiDriverConfigs[i])
+		results := resourceapply.DeleteAll(ctx, clientHolder, c.eventRecorder, namespacedAssetFunc, files...)
+		for _, result := range results {
+			if result.Error != nil {
+				errs = append(errs, fmt.Errorf("failed to delete %q: %w", result.File, result.Error))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// teardownAssets returns management cluster assets of a CSI driver operator in the order
+// in which they should be deleted.
+func teardownAssets(cfg csioperatorclient.CSIOperatorConfig) []string {
+	var files []string
+	if cfg.DeploymentAsset != "" {
+		files = append(files, cfg.DeploymentAsset)
+	}
+	if cfg.MgmtOperatorConfigAsset != "" {
+		files = append(files, cfg.MgmtOperatorConfigAsset)
+	}
+	for i := len(cfg.MgmtStaticAssets) - 1; i >= 0; i-- {
+		files = append(files, cfg.MgmtStaticAssets[i])
+	}
+	return files
+}
+
+// isOperatorBeingDeleted returns true once the Storage CR is marked for deletion.
+// Controllers that create management cluster objects use it to stop re-creating
+// the objects removed by HyperShiftTeardownController.
+func isOperatorBeingDeleted(operatorClient v1helpers.OperatorClient) (bool, error) {
+	meta, err := operatorClient.GetObjectMeta()
+	if err != nil {
+		return false, err
+	}
+	return meta.DeletionTimestamp != nil, nil
+}
@@ -0,0 +1,105 @@
+package csidriveroperator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const teardownTestNamespace = "clusters-test"
+
+func TestTeardownAssets(t *testing.T) {
+	cfg := csioperatorclient.CSIOperatorConfig{
+		DeploymentAsset:         "deployment.yaml",
+		MgmtOperatorConfigAsset: "configmap.yaml",
+		MgmtStaticAssets:        []string{"role.yaml", "sa.yaml", "service.yaml", "rolebinding.yaml"},
+	}
+	expected := []string{"deployment.yaml", "configmap.yaml", "rolebinding.yaml", "service.yaml", "sa.yaml", "role.yaml"}
+	assert.Equal(t, expected, teardownAssets(cfg))
+}
+
+func TestHyperShiftTeardownController(t *testing.T) {
+	cfg := csioperatorclient.GetAWSEBSCSIOperatorConfig(true)
+
+	tests := []struct {
+		name               string
+		deletionTimestamp  *metav1.Time
+		finalizers         []string
+		expectedFinalizers []string
+		expectDeleted      bool
+	}{
+		{
+			name:               "finalizer is added to live CR",
+			expectedFinalizers: []string{HyperShiftTeardownFinalizer},
+			expectDeleted:      false,
+		},
+		{
+			name:               "finalizer is not duplicated",
+			finalizers:         []string{HyperShiftTeardownFinalizer},
+			expectedFinalizers: []string{HyperShiftTeardownFinalizer},
+			expectDeleted:      false,
+		},
+		{
+			name:               "deleted CR removes mgmt objects and releases finalizer",
+			deletionTimestamp:  &metav1.Time{Time: time.Now()},
+			finalizers:         []string{"other", HyperShiftTeardownFinalizer},
+			expectedFinalizers: []string{"other"},
+			expectDeleted:      true,
+		},
+		{
+			name:               "deleted CR without finalizer is left alone",
+			deletionTimestamp:  &metav1.Time{Time: time.Now()},
+			finalizers:         []string{"other"},
+			expectedFinalizers: []string{"other"},
+			expectDeleted:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := csoclients.GetCR()
+			cr.DeletionTimestamp = test.deletionTimestamp
+			cr.Finalizers = test.finalizers
+			guestClients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				OperatorObjects: []runtime.Object{cr},
+			})
+			mgmtClients := csoclients.NewFakeMgmtClients(&csoclients.FakeTestObjects{
+				CoreObjects: []runtime.Object{
+					&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "aws-ebs-csi-driver-operator", Namespace: teardownTestNamespace}},
+					&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: cfg.CSIDriverDeploymentName, Namespace: teardownTestNamespace}},
+				},
+			})
+
+			c := &HyperShiftTeardownController{
+				operatorClient:   guestClients.OperatorClient,
+				mgmtClient:       mgmtClients,
+				controlNamespace: teardownTestNamespace,
+				csiDriverConfigs: []csioperatorclient.CSIOperatorConfig{cfg},
+				eventRecorder:    events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())),
+			}
+
+			err := c.sync(context.TODO(), nil)
+			assert.NoError(t, err)
+
+			meta, err := guestClients.OperatorClient.GetObjectMeta()
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedFinalizers, meta.Finalizers)
+
+			_, err = mgmtClients.KubeClient.AppsV1().Deployments(teardownTestNamespace).Get(context.TODO(), cfg.CSIDriverDeploymentName, metav1.GetOptions{})
+			assert.Equal(t, test.expectDeleted, apierrors.IsNotFound(err), "unexpected Deployment state: %v", err)
+			_, err = mgmtClients.KubeClient.CoreV1().ServiceAccounts(teardownTestNamespace).Get(context.TODO(), "aws-ebs-csi-driver-operator", metav1.GetOptions{})
+			assert.Equal(t, test.expectDeleted, apierrors.IsNotFound(err), "unexpected ServiceAccount state: %v", err)
+		})
+	}
+}
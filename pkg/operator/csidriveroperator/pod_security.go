@@ -0,0 +1,158 @@
+package csidriveroperator
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// envPodSecurityProfile selects the pod security profile of Deployments in the management cluster.
+	// Supported values are podSecurityProfileRestricted and podSecurityProfileNone, the default.
+	envPodSecurityProfile = "POD_SECURITY_PROFILE"
+	// envPodSecurityFSGroup sets fsGroup of Deployments in the management cluster when the
+	// Restricted profile is used.
+	envPodSecurityFSGroup = "POD_SECURITY_FS_GROUP"
+
+	// HostedControlPlane annotations that override the env. variables above.
+	hcpPodSecurityProfileAnnotation = "storage.openshift.io/pod-security-profile"
+	hcpPodSecurityFSGroupAnnotation = "storage.openshift.io/pod-security-fs-group"
+
+	podSecurityProfileRestricted = "Restricted"
+	podSecurityProfileNone       = "None"
+
+	capabilityNetBindService corev1.Capability = "NET_BIND_SERVICE"
+)
+
+// podSecurityProfile describes how Deployments in the management cluster are hardened.
+// Management clusters without Security Context Constraints (for example AKS) do not
+// default any security context fields, so CSO must set them itself.
+type podSecurityProfile struct {
+	restricted bool
+	fsGroup    *int64
+}
+
+// podSecurityProfileFromHCP returns the pod security profile configured through env. variables,
+// overridden by annotations of the HostedControlPlane. The Restricted profile is opt-in, existing
+// management clusters keep their Deployments unchanged when nothing is configured.
+func podSecurityProfileFromHCP(hcp *unstructured.Unstructured) (podSecurityProfile, error) {
+	var annotations map[string]string
+	if hcp != nil {
+		annotations = hcp.GetAnnotations()
+	}
+
+	profileName := os.Getenv(envPodSecurityProfile)
+	if value, ok := annotations[hcpPodSecurityProfileAnnotation]; ok {
+		profileName = value
+	}
+
+	profile := podSecurityProfile{}
+	switch profileName {
+	case podSecurityProfileRestricted:
+		profile.restricted = true
+	case "", podSecurityProfileNone:
+		profile.restricted = false
+	default:
+		return profile, fmt.Errorf("invalid pod security profile %q: must be %q or %q", profileName, podSecurityProfileRestricted, podSecurityProfileNone)
+	}
+
+	fsGroup := os.Getenv(envPodSecurityFSGroup)
+	if value, ok := annotations[hcpPodSecurityFSGroupAnnotation]; ok {
+		fsGroup = value
+	}
+	if fsGroup != "" {
+		fsGroupValue, err := strconv.ParseInt(fsGroup, 10, 64)
+		if err != nil {
+			return profile, fmt.Errorf("invalid pod security fsGroup %q: must be a valid integer: %w", fsGroup, err)
+		}
+		if fsGroupValue < 0 {
+			return profile, fmt.Errorf("invalid pod security fsGroup %q: must be non-negative", fsGroup)
+		}
+		profile.fsGroup = &fsGroupValue
+	}
+	return profile, nil
+}
+
+// applyPodSecurityProfile hardens the pod spec so it passes the Kubernetes "restricted"
+// Pod Security Standard. Fields explicitly set by the asset are kept when they are
+// compatible with the standard, e.g. readOnlyRootFilesystem: false of a container that
+// needs to write to its root filesystem.
+// runAsNonRoot is always set, as the standard requires. Without a runAsUser, e.g. from
+// RUN_AS_USER, the user comes from the image and kubelet refuses to start the pod with
+// CreateContainerConfigError when the image runs as root.
+func applyPodSecurityProfile(podSpec *corev1.PodSpec, profile podSecurityProfile) error {
+	if !profile.restricted {
+		return nil
+	}
+
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	sc := podSpec.SecurityContext
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		return fmt.Errorf("pod security profile %s does not allow runAsUser 0", podSecurityProfileRestricted)
+	}
+	sc.RunAsNonRoot = ptr.To(true)
+	if sc.SeccompProfile == nil || sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	switch {
+	case profile.fsGroup != nil:
+		sc.FSGroup = profile.fsGroup
+	case sc.FSGroup == nil && sc.RunAsUser != nil:
+		sc.FSGroup = sc.RunAsUser
+	}
+
+	for i := range podSpec.InitContainers {
+		if err := applyContainerSecurityProfile(&podSpec.InitContainers[i]); err != nil {
+			return err
+		}
+	}
+	for i := range podSpec.Containers {
+		if err := applyContainerSecurityProfile(&podSpec.Containers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyContainerSecurityProfile(container *corev1.Container) error {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	sc := container.SecurityContext
+	if sc.Privileged != nil && *sc.Privileged {
+		return fmt.Errorf("pod security profile %s does not allow privileged container %s", podSecurityProfileRestricted, container.Name)
+	}
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		return fmt.Errorf("pod security profile %s does not allow runAsUser 0 in container %s", podSecurityProfileRestricted, container.Name)
+	}
+	if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+		sc.RunAsNonRoot = nil
+	}
+	sc.AllowPrivilegeEscalation = ptr.To(false)
+	if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		sc.SeccompProfile = nil
+	}
+
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{}
+	}
+	sc.Capabilities.Drop = []corev1.Capability{"ALL"}
+	var add []corev1.Capability
+	for _, capability := range sc.Capabilities.Add {
+		if capability == capabilityNetBindService {
+			add = append(add, capability)
+		}
+	}
+	sc.Capabilities.Add = add
+
+	if sc.ReadOnlyRootFilesystem == nil {
+		sc.ReadOnlyRootFilesystem = ptr.To(true)
+	}
+	return nil
+}
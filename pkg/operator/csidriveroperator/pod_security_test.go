@@ -0,0 +1,325 @@
+package csidriveroperator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+var mgmtDeploymentAssets = []string{
+	"csidriveroperators/aws-ebs/hypershift/mgmt/generated/apps_v1_deployment_aws-ebs-csi-driver-operator.yaml",
+	"csidriveroperators/azure-disk/hypershift/mgmt/generated/apps_v1_deployment_azure-disk-csi-driver-operator.yaml",
+	"csidriveroperators/azure-file/hypershift/mgmt/generated/apps_v1_deployment_azure-file-csi-driver-operator.yaml",
+	"csidriveroperators/gcp-pd/hypershift/mgmt/generated/apps_v1_deployment_gcp-pd-csi-driver-operator.yaml",
+	"csidriveroperators/ibm-vpc-block/hypershift/mgmt/generated/apps_v1_deployment_ibm-vpc-block-csi-driver-operator.yaml",
+	"csidriveroperators/openstack-cinder/hypershift/mgmt/generated/apps_v1_deployment_openstack-cinder-csi-driver-operator.yaml",
+	"csidriveroperators/openstack-manila/hypershift/mgmt/generated/apps_v1_deployment_manila-csi-driver-operator.yaml",
+	"csidriveroperators/vsphere/hypershift/mgmt/generated/apps_v1_deployment_vmware-vsphere-csi-driver-operator.yaml",
+}
+
+// isRestrictedVolume returns true for volume types allowed by the "restricted" Pod Security Standard.
+func isRestrictedVolume(v corev1.Volume) bool {
+	s := v.VolumeSource
+	return s.ConfigMap != nil || s.CSI != nil || s.DownwardAPI != nil || s.EmptyDir != nil ||
+		s.Ephemeral != nil || s.PersistentVolumeClaim != nil || s.Projected != nil || s.Secret != nil
+}
+
+// checkRestrictedPodSecurity returns violations of the "restricted" Pod Security Standard
+// (https://kubernetes.io/docs/concepts/security/pod-security-standards/#restricted) in the pod spec.
+func checkRestrictedPodSecurity(podSpec *corev1.PodSpec) []string {
+	var violations []string
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		violations = append(violations, "host namespaces are not allowed")
+	}
+	for _, v := range podSpec.Volumes {
+		if !isRestrictedVolume(v) {
+			violations = append(violations, fmt.Sprintf("volume %s has a restricted type", v.Name))
+		}
+	}
+
+	podSC := podSpec.SecurityContext
+	if podSC == nil {
+		podSC = &corev1.PodSecurityContext{}
+	}
+	if podSC.RunAsUser != nil && *podSC.RunAsUser == 0 {
+		violations = append(violations, "pod runAsUser is 0")
+	}
+	podSeccompOK := podSC.SeccompProfile != nil && podSC.SeccompProfile.Type != corev1.SeccompProfileTypeUnconfined
+	if podSC.SeccompProfile != nil && podSC.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		violations = append(violations, "pod seccompProfile is Unconfined")
+	}
+
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container %s uses hostPort", c.Name))
+			}
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("container %s is privileged", c.Name))
+		}
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %s allows privilege escalation", c.Name))
+		}
+		runAsNonRoot := sc.RunAsNonRoot
+		if runAsNonRoot == nil {
+			runAsNonRoot = podSC.RunAsNonRoot
+		}
+		if runAsNonRoot == nil || !*runAsNonRoot {
+			violations = append(violations, fmt.Sprintf("container %s does not set runAsNonRoot", c.Name))
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container %s runAsUser is 0", c.Name))
+		}
+		if sc.SeccompProfile != nil {
+			if sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+				violations = append(violations, fmt.Sprintf("container %s seccompProfile is Unconfined", c.Name))
+			}
+		} else if !podSeccompOK {
+			violations = append(violations, fmt.Sprintf("container %s does not set seccompProfile", c.Name))
+		}
+		dropsAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+			for _, capability := range sc.Capabilities.Add {
+				if capability != capabilityNetBindService {
+					violations = append(violations, fmt.Sprintf("container %s adds capability %s", c.Name, capability))
+				}
+			}
+		}
+		if !dropsAll {
+			violations = append(violations, fmt.Sprintf("container %s does not drop ALL capabilities", c.Name))
+		}
+	}
+	return violations
+}
+
+func TestApplyPodSecurityProfileMgmtDeployments(t *testing.T) {
+	replacer := strings.NewReplacer("${CONTROLPLANE_NAMESPACE}", "clusters-test")
+	profile := podSecurityProfile{restricted: true, fsGroup: ptr.To[int64](1001)}
+
+	for _, asset := range mgmtDeploymentAssets {
+		t.Run(asset, func(t *testing.T) {
+			deployment, err := csoutils.GetRequiredDeployment(asset, &operatorv1.OperatorSpec{}, nil, nil, nil, replacer)
+			if err != nil {
+				t.Fatalf("failed to read Deployment: %v", err)
+			}
+			podSpec := &deployment.Spec.Template.Spec
+
+			if err := applyPodSecurityProfile(podSpec, profile); err != nil {
+				t.Fatalf("applyPodSecurityProfile() failed: %v", err)
+			}
+			if violations := checkRestrictedPodSecurity(podSpec); len(violations) > 0 {
+				t.Errorf("Deployment violates the restricted Pod Security Standard: %v", violations)
+			}
+			assert.Equal(t, ptr.To[int64](1001), podSpec.SecurityContext.FSGroup)
+		})
+	}
+}
+
+func TestApplyPodSecurityProfile(t *testing.T) {
+	tests := []struct {
+		name          string
+		profile       podSecurityProfile
+		podSpec       *corev1.PodSpec
+		expectErr     bool
+		expectChanged bool
+	}{
+		{
+			name:    "disabled profile does not touch the pod",
+			profile: podSecurityProfile{},
+			podSpec: &corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "operator"}},
+			},
+			expectChanged: false,
+		},
+		{
+			name:    "empty pod is hardened",
+			profile: podSecurityProfile{restricted: true},
+			podSpec: &corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init"}},
+				Containers:     []corev1.Container{{Name: "operator"}, {Name: "token-minter"}},
+			},
+			expectChanged: true,
+		},
+		{
+			name:    "unsafe settings are overridden",
+			profile: podSecurityProfile{restricted: true},
+			podSpec: &corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+				},
+				Containers: []corev1.Container{{
+					Name: "operator",
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: ptr.To(true),
+						RunAsNonRoot:             ptr.To(false),
+						Capabilities: &corev1.Capabilities{
+							Add: []corev1.Capability{"SYS_ADMIN", capabilityNetBindService},
+						},
+					},
+				}},
+			},
+			expectChanged: true,
+		},
+		{
+			name:    "privileged container is rejected",
+			profile: podSecurityProfile{restricted: true},
+			podSpec: &corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:            "operator",
+					SecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+				}},
+			},
+			expectErr: true,
+		},
+		{
+			name:    "root user is rejected",
+			profile: podSecurityProfile{restricted: true},
+			podSpec: &corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](0)},
+				Containers:      []corev1.Container{{Name: "operator"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.podSpec.DeepCopy()
+			err := applyPodSecurityProfile(tt.podSpec, tt.profile)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if !tt.expectChanged {
+				assert.Equal(t, original, tt.podSpec)
+				return
+			}
+			assert.Empty(t, checkRestrictedPodSecurity(tt.podSpec))
+			for _, c := range tt.podSpec.Containers {
+				assert.Equal(t, ptr.To(true), c.SecurityContext.ReadOnlyRootFilesystem, "container %s", c.Name)
+			}
+		})
+	}
+}
+
+func TestApplyPodSecurityProfileImageUser(t *testing.T) {
+	// Without runAsUser the pod runs as the image USER, kubelet refuses to start it as root
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "operator"}},
+	}
+	err := applyPodSecurityProfile(podSpec, podSecurityProfile{restricted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr.To(true), podSpec.SecurityContext.RunAsNonRoot)
+	assert.Nil(t, podSpec.SecurityContext.RunAsUser)
+	assert.Empty(t, checkRestrictedPodSecurity(podSpec))
+	assert.Equal(t, ptr.To(false), podSpec.Containers[0].SecurityContext.AllowPrivilegeEscalation)
+	assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, podSpec.SecurityContext.SeccompProfile.Type)
+}
+
+func TestApplyPodSecurityProfileKeepsWritableRootFilesystem(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](1000)},
+		Containers: []corev1.Container{{
+			Name:            "operator",
+			SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(false)},
+		}},
+	}
+	err := applyPodSecurityProfile(podSpec, podSecurityProfile{restricted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, ptr.To(false), podSpec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	// fsGroup defaults to runAsUser
+	assert.Equal(t, ptr.To[int64](1000), podSpec.SecurityContext.FSGroup)
+}
+
+func TestPodSecurityProfileFromHCP(t *testing.T) {
+	hcpWithAnnotations := func(annotations map[string]string) *unstructured.Unstructured {
+		hcp := &unstructured.Unstructured{Object: map[string]any{}}
+		hcp.SetAnnotations(annotations)
+		return hcp
+	}
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		hcp       *unstructured.Unstructured
+		expected  podSecurityProfile
+		expectErr bool
+	}{
+		{
+			name:     "nothing configured",
+			hcp:      hcpWithAnnotations(nil),
+			expected: podSecurityProfile{},
+		},
+		{
+			name:     "RUN_AS_USER does not enable Restricted",
+			env:      map[string]string{"RUN_AS_USER": "1000"},
+			hcp:      hcpWithAnnotations(nil),
+			expected: podSecurityProfile{},
+		},
+		{
+			name:     "env enables Restricted",
+			env:      map[string]string{envPodSecurityProfile: podSecurityProfileRestricted, envPodSecurityFSGroup: "2000"},
+			hcp:      hcpWithAnnotations(nil),
+			expected: podSecurityProfile{restricted: true, fsGroup: ptr.To[int64](2000)},
+		},
+		{
+			name:     "annotation enables Restricted",
+			hcp:      hcpWithAnnotations(map[string]string{hcpPodSecurityProfileAnnotation: podSecurityProfileRestricted}),
+			expected: podSecurityProfile{restricted: true},
+		},
+		{
+			name: "annotations override env",
+			env:  map[string]string{envPodSecurityProfile: podSecurityProfileRestricted, envPodSecurityFSGroup: "2000"},
+			hcp: hcpWithAnnotations(map[string]string{
+				hcpPodSecurityProfileAnnotation: podSecurityProfileNone,
+				hcpPodSecurityFSGroupAnnotation: "3000",
+			}),
+			expected: podSecurityProfile{restricted: false, fsGroup: ptr.To[int64](3000)},
+		},
+		{
+			name:      "invalid profile",
+			hcp:       hcpWithAnnotations(map[string]string{hcpPodSecurityProfileAnnotation: "Baseline"}),
+			expectErr: true,
+		},
+		{
+			name:      "invalid fsGroup",
+			env:       map[string]string{envPodSecurityFSGroup: "-1"},
+			hcp:       hcpWithAnnotations(nil),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"RUN_AS_USER", envPodSecurityProfile, envPodSecurityFSGroup} {
+				t.Setenv(name, tt.env[name])
+			}
+			profile, err := podSecurityProfileFromHCP(tt.hcp)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, profile)
+		})
+	}
+}
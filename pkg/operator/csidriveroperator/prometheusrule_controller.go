@@ -0,0 +1,142 @@
+package csidriveroperator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	operatorv1listers "github.com/openshift/client-go/operator/listers/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/prometheusrule"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	promclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// alertsConfigMapName is an optional ConfigMap in the CSO namespace with configuration of
+	// alerts of CSI drivers, see alertsConfig.
+	alertsConfigMapName = "csi-driver-alerts"
+)
+
+// alertsConfig is the content of alertsConfigMapName ConfigMap.
+type alertsConfig struct {
+	// DisabledDrivers are names of CSI drivers whose PrometheusRules are removed.
+	DisabledDrivers []string `yaml:"disabledDrivers,omitempty"`
+}
+
+// PrometheusRuleController applies PrometheusRule of a CSI driver. The rule is removed when alerts
+// of the driver are disabled in csi-driver-alerts ConfigMap or when its ClusterCSIDriver is Removed.
+type PrometheusRuleController struct {
+	name                   string
+	operatorClient         v1helpers.OperatorClient
+	monitoringClient       promclient.Interface
+	configMapLister        corelisters.ConfigMapLister
+	clusterCSIDriverLister operatorv1listers.ClusterCSIDriverLister
+	csiDriverName          string
+	prometheusRuleAsset    string
+	eventRecorder          events.Recorder
+}
+
+func NewPrometheusRuleController(
+	clients *csoclients.Clients,
+	csiOperatorConfig csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+
+	name := csiOperatorConfig.ConditionPrefix + "CSIDriverOperatorPrometheusRuleController"
+	c := &PrometheusRuleController{
+		name:                   name,
+		operatorClient:         clients.OperatorClient,
+		monitoringClient:       clients.MonitoringClient,
+		configMapLister:        clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+		clusterCSIDriverLister: clients.OperatorInformers.Operator().V1().ClusterCSIDrivers().Lister(),
+		csiDriverName:          csiOperatorConfig.CSIDriverName,
+		prometheusRuleAsset:    csiOperatorConfig.PrometheusRuleAsset,
+		eventRecorder:          eventRecorder.WithComponentSuffix(csiOperatorConfig.ConditionPrefix + "-prometheus-rule-controller"),
+	}
+	return factory.New().
+		WithSync(c.Sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			clients.OperatorClient.Informer(),
+			clients.MonitoringInformer.Monitoring().V1().PrometheusRules().Informer(),
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+			clients.OperatorInformers.Operator().V1().ClusterCSIDrivers().Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		WithPostStartHooks(initalSync).
+		ToController(name, eventRecorder)
+}
+
+func (c *PrometheusRuleController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("%s sync started", c.name)
+	defer klog.V(4).Infof("%s sync finished", c.name)
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	ruleBytes, err := assets.ReadFile(c.prometheusRuleAsset)
+	if err != nil {
+		return err
+	}
+	rule, err := prometheusrule.ReadPrometheusRule(ruleBytes)
+	if err != nil {
+		return fmt.Errorf("invalid asset %s: %w", c.prometheusRuleAsset, err)
+	}
+
+	disabled, err := c.alertsDisabled()
+	if err != nil {
+		return err
+	}
+	if disabled {
+		_, err = prometheusrule.DeletePrometheusRule(ctx, c.monitoringClient, c.eventRecorder, rule)
+		return err
+	}
+	_, _, err = prometheusrule.ApplyPrometheusRule(ctx, c.monitoringClient, c.eventRecorder, rule)
+	return err
+}
+
+// alertsDisabled returns true when the CSI driver alerts are disabled by the admin or when
+// the CSI driver itself is Removed.
+func (c *PrometheusRuleController) alertsDisabled() (bool, error) {
+	ccd, err := c.clusterCSIDriverLister.Get(c.csiDriverName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if ccd != nil && ccd.Spec.ManagementState == operatorapi.Removed {
+		return true, nil
+	}
+
+	cfg, err := c.parseAlertsConfig()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(cfg.DisabledDrivers, c.csiDriverName), nil
+}
+
+func (c *PrometheusRuleController) parseAlertsConfig() (*alertsConfig, error) {
+	// All alerts are enabled by default
+	cfg := &alertsConfig{}
+	if _, err := csoutils.ParseOperatorConfigMap(c.configMapLister, alertsConfigMapName, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,129 @@
+package csidriveroperator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/prometheusrule"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func vSphereClusterCSIDriver(managementState operatorv1.ManagementState) *operatorv1.ClusterCSIDriver {
+	return &operatorv1.ClusterCSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: csioperatorclient.VMwareVSphereDriverName},
+		Spec: operatorv1.ClusterCSIDriverSpec{
+			OperatorSpec: operatorv1.OperatorSpec{ManagementState: managementState},
+		},
+	}
+}
+
+func alertsConfigMap(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: csoclients.OperatorNamespace, Name: alertsConfigMapName},
+		Data:       map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+func TestPrometheusRuleController(t *testing.T) {
+	const ruleName = "vmware-vsphere-csi-driver-operator"
+
+	tests := []struct {
+		name        string
+		ccd         *operatorv1.ClusterCSIDriver
+		configMap   *corev1.ConfigMap
+		expectRule  bool
+		expectError bool
+	}{
+		{
+			name:       "alerts enabled",
+			ccd:        vSphereClusterCSIDriver(operatorv1.Managed),
+			expectRule: true,
+		},
+		{
+			name:       "other driver disabled",
+			ccd:        vSphereClusterCSIDriver(operatorv1.Managed),
+			configMap:  alertsConfigMap("disabledDrivers:\n- ebs.csi.aws.com\n"),
+			expectRule: true,
+		},
+		{
+			name:      "alerts disabled",
+			ccd:       vSphereClusterCSIDriver(operatorv1.Managed),
+			configMap: alertsConfigMap("disabledDrivers:\n- csi.vsphere.vmware.com\n"),
+		},
+		{
+			name: "driver removed",
+			ccd:  vSphereClusterCSIDriver(operatorv1.Removed),
+		},
+		{
+			name:        "invalid config",
+			ccd:         vSphereClusterCSIDriver(operatorv1.Managed),
+			configMap:   alertsConfigMap("alertsDisabled: true\n"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := csioperatorclient.GetVMwareVSphereCSIOperatorConfig(false)
+			var coreObjects []runtime.Object
+			if tt.configMap != nil {
+				coreObjects = append(coreObjects, tt.configMap)
+			}
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     coreObjects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR(), tt.ccd},
+			})
+			// The rule exists from a previous sync, it must be removed when disabled.
+			ruleBytes, err := assets.ReadFile(cfg.PrometheusRuleAsset)
+			if !assert.NoError(t, err) {
+				return
+			}
+			existing, err := prometheusrule.ReadPrometheusRule(ruleBytes)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = clients.MonitoringClient.MonitoringV1().PrometheusRules(existing.Namespace).Create(context.TODO(), existing, metav1.CreateOptions{})
+			assert.NoError(t, err)
+
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewPrometheusRuleController(clients, cfg, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.OperatorInformers.Operator().V1().ClusterCSIDrivers().Informer().HasSynced,
+				clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err = ctrl.Sync(context.TODO(), nil)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = clients.MonitoringClient.MonitoringV1().PrometheusRules(csoclients.CSIOperatorNamespace).Get(context.TODO(), ruleName, metav1.GetOptions{})
+			if tt.expectRule {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err), "PrometheusRule must be removed, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package csidriveroperator
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+)
+
+const (
+	shortTermTokenVolumeName = "bound-sa-token"
+	// One hour is the default expiration used by the cloud SDKs' token refresh.
+	shortTermTokenExpirationSeconds = 3600
+
+	shortTermCredentialsConditionSuffix = "ShortTermCredentials"
+	missingCloudIdentityReason          = "MissingCloudIdentity"
+)
+
+// injectShortTermCredentials mounts a projected service account token into all containers of
+// the Deployment and adds the cloud specific env vars.
+func injectShortTermCredentials(deployment *appsv1.Deployment, cfg *csioperatorclient.ShortTermCredentials) {
+	podSpec := &deployment.Spec.Template.Spec
+	hasVolume := false
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == shortTermTokenVolumeName {
+			hasVolume = true
+			break
+		}
+	}
+	if !hasVolume {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: shortTermTokenVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{
+							ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+								Audience:          cfg.TokenAudience,
+								ExpirationSeconds: ptr.To[int64](shortTermTokenExpirationSeconds),
+								Path:              "token",
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if !mountsPath(*container, csioperatorclient.ShortTermTokenDir) {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      shortTermTokenVolumeName,
+				MountPath: csioperatorclient.ShortTermTokenDir,
+				ReadOnly:  true,
+			})
+		}
+		for _, env := range cfg.Env {
+			container.Env = setEnv(container.Env, env)
+		}
+	}
+}
+
+func setEnv(envs []corev1.EnvVar, env corev1.EnvVar) []corev1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+	return append(envs, env)
+}
+
+// shortTermCredentialsCondition returns <CSI driver name>ShortTermCredentialsDegraded condition
+// with the result of the credentials Secret check.
+func shortTermCredentialsCondition(prefix string, secret *corev1.Secret, checkErr error) operatorv1.OperatorCondition {
+	cond := operatorv1.OperatorCondition{
+		Type:   prefix + shortTermCredentialsConditionSuffix + operatorv1.OperatorStatusTypeDegraded,
+		Status: operatorv1.ConditionFalse,
+	}
+	if checkErr != nil {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = missingCloudIdentityReason
+		cond.Message = fmt.Sprintf("Credentials Secret %s/%s cannot be used with short-term credentials: %v", secret.Namespace, secret.Name, checkErr)
+	}
+	return cond
+}
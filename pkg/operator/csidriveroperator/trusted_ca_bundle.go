@@ -0,0 +1,68 @@
+package csidriveroperator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	trustedCABundleAsset         = "csidriveroperators/trusted-ca-bundle.yaml"
+	trustedCABundleConfigMapName = "csi-driver-operators-trusted-ca-bundle"
+	trustedCABundleKey           = "ca-bundle.crt"
+	trustedCABundleMountPath     = "/etc/pki/ca-trust/extracted/pem"
+
+	// trustedCABundleHashAnnotation is set on a Deployment and its pod template to the hash
+	// of the trusted CA bundle ConfigMap, so pods are restarted when the bundle changes.
+	trustedCABundleHashAnnotation = "storage.openshift.io/trusted-ca-bundle-hash"
+)
+
+// injectTrustedCABundle mounts the trusted CA bundle ConfigMap into all containers of the
+// Deployment that do not mount their own CA bundle and annotates the Deployment with the
+// bundle hash. Nothing is mounted until the bundle is injected into the ConfigMap, because
+// an empty mount would hide the system CA certificates of the image.
+func injectTrustedCABundle(deployment *appsv1.Deployment, cm *corev1.ConfigMap) error {
+	if _, ok := cm.Data[trustedCABundleKey]; !ok {
+		return nil
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	var containerNames []string
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		if !mountsPath(container, trustedCABundleMountPath) {
+			containerNames = append(containerNames, container.Name)
+		}
+	}
+	if len(containerNames) > 0 {
+		if err := v1helpers.InjectTrustedCAIntoContainers(podSpec, cm.Name, containerNames); err != nil {
+			return fmt.Errorf("failed to inject trusted CA bundle: %w", err)
+		}
+	}
+
+	hash, err := resourcehash.GetConfigMapHash(cm)
+	if err != nil {
+		return fmt.Errorf("failed to compute hash of ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Annotations[trustedCABundleHashAnnotation] = hash
+	deployment.Spec.Template.Annotations[trustedCABundleHashAnnotation] = hash
+	return nil
+}
+
+func mountsPath(container corev1.Container, path string) bool {
+	for _, mount := range container.VolumeMounts {
+		if strings.TrimSuffix(mount.MountPath, "/") == path {
+			return true
+		}
+	}
+	return false
+}
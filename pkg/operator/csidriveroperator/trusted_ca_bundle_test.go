@@ -0,0 +1,71 @@
+package csidriveroperator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func trustedCABundleTestDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "operator"},
+						{
+							Name: "own-bundle",
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "trusted-ca-bundle", MountPath: trustedCABundleMountPath + "/"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func trustedCABundleTestConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: trustedCABundleConfigMapName, Namespace: "openshift-cluster-csi-drivers"},
+		Data:       data,
+	}
+}
+
+func TestInjectTrustedCABundle(t *testing.T) {
+	t.Run("bundle not injected yet", func(t *testing.T) {
+		deployment := trustedCABundleTestDeployment()
+		err := injectTrustedCABundle(deployment, trustedCABundleTestConfigMap(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, trustedCABundleTestDeployment(), deployment, "Deployment must not be changed")
+	})
+
+	t.Run("bundle injected", func(t *testing.T) {
+		deployment := trustedCABundleTestDeployment()
+		err := injectTrustedCABundle(deployment, trustedCABundleTestConfigMap(map[string]string{trustedCABundleKey: "ca1"}))
+		assert.NoError(t, err)
+
+		podSpec := deployment.Spec.Template.Spec
+		if assert.Len(t, podSpec.Volumes, 1) {
+			assert.Equal(t, trustedCABundleConfigMapName, podSpec.Volumes[0].ConfigMap.Name)
+		}
+		if assert.Len(t, podSpec.Containers[0].VolumeMounts, 1) {
+			assert.Equal(t, podSpec.Volumes[0].Name, podSpec.Containers[0].VolumeMounts[0].Name)
+			assert.Equal(t, trustedCABundleMountPath, podSpec.Containers[0].VolumeMounts[0].MountPath)
+		}
+		assert.Len(t, podSpec.Containers[1].VolumeMounts, 1, "container with its own CA bundle must be kept")
+
+		hash := deployment.Spec.Template.Annotations[trustedCABundleHashAnnotation]
+		assert.NotEmpty(t, hash)
+		assert.Equal(t, hash, deployment.Annotations[trustedCABundleHashAnnotation])
+
+		changed := trustedCABundleTestDeployment()
+		err = injectTrustedCABundle(changed, trustedCABundleTestConfigMap(map[string]string{trustedCABundleKey: "ca2"}))
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, changed.Spec.Template.Annotations[trustedCABundleHashAnnotation], "bundle change must roll out the Deployment")
+	})
+}
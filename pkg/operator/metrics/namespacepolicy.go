@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+type namespacePolicyCollector struct {
+	metrics.BaseStableCollector
+	namespaceLister corelisters.NamespaceLister
+}
+
+var (
+	namespacePolicyDesc = metrics.NewDesc(
+		"openshift_cluster_storage_namespaces_by_change_policy",
+		"Number of namespaces by their effective storage performant policy. Namespaces without a policy label use the Kubernetes default.",
+		[]string{"policy_type", "policy"},
+		nil,
+		metrics.ALPHA,
+		"",
+	)
+
+	// namespacePolicyLabels maps the policy_type metric label to the namespace label and its Kubernetes default.
+	namespacePolicyLabels = map[string]struct {
+		label        string
+		defaultValue string
+	}{
+		"fsGroupChangePolicy": {performantpolicy.FSGroupChangePolicyLabel, string(corev1.FSGroupChangeAlways)},
+		"seLinuxChangePolicy": {performantpolicy.SELinuxChangePolicyLabel, string(corev1.SELinuxChangePolicyMountOption)},
+	}
+)
+
+var registerNamespacePolicyMetrics sync.Once
+
+func InitializeNamespacePolicyMetrics(clients *csoclients.Clients) {
+	klog.Infof("Registering namespace storage policy metric collector")
+	registerNamespacePolicyMetrics.Do(func() {
+		legacyregistry.CustomMustRegister(&namespacePolicyCollector{
+			namespaceLister: clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Lister(),
+		})
+	})
+}
+
+var _ metrics.StableCollector = &namespacePolicyCollector{}
+
+func (c *namespacePolicyCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+	ch <- namespacePolicyDesc
+}
+
+func (c *namespacePolicyCollector) CollectWithStability(ch chan<- metrics.Metric) {
+	namespaces, err := c.namespaceLister.List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for policyType, counts := range countNamespacePolicies(namespaces) {
+		for policy, count := range counts {
+			ch <- metrics.NewLazyConstMetric(namespacePolicyDesc, metrics.GaugeValue, float64(count), policyType, policy)
+		}
+	}
+}
+
+// countNamespacePolicies returns number of namespaces per policy type and effective policy.
+func countNamespacePolicies(namespaces []*corev1.Namespace) map[string]map[string]int {
+	counts := map[string]map[string]int{}
+	for policyType, policyLabel := range namespacePolicyLabels {
+		counts[policyType] = map[string]int{}
+		for _, ns := range namespaces {
+			policy, found := ns.Labels[policyLabel.label]
+			if !found {
+				policy = policyLabel.defaultValue
+			}
+			counts[policyType][policy]++
+		}
+	}
+	return counts
+}
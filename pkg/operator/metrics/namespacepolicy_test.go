@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCountNamespacePolicies(t *testing.T) {
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fsgroup", Labels: map[string]string{
+			performantpolicy.FSGroupChangePolicyLabel: "OnRootMismatch",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "both", Labels: map[string]string{
+			performantpolicy.FSGroupChangePolicyLabel: "OnRootMismatch",
+			performantpolicy.SELinuxChangePolicyLabel: "Recursive",
+		}}},
+	}
+	expected := map[string]map[string]int{
+		"fsGroupChangePolicy": {"Always": 1, "OnRootMismatch": 2},
+		"seLinuxChangePolicy": {"MountOption": 2, "Recursive": 1},
+	}
+	if got := countNamespacePolicies(namespaces); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
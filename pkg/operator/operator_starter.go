@@ -9,12 +9,17 @@ import (
 	"github.com/openshift/api/features"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/admissionpolicy"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/configobservation/configobservercontroller"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/defaultstorageclass"
 	metrics "github.com/openshift/cluster-storage-operator/pkg/operator/metrics"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/selinuxmountreadiness"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/storagealerts"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/storagecapacity"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/tlscompliance"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/volumedatasourcevalidator"
 	"github.com/openshift/cluster-storage-operator/pkg/operator/vsphereproblemdetector"
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
@@ -104,6 +109,20 @@ func (csr *commonStarter) CreateCommonControllers() error {
 	)
 	csr.controllers = append(csr.controllers, volumeDataSourceValidatorController)
 
+	storageAlertsController := storagealerts.NewController(
+		csr.commonClients,
+		resync,
+		csr.eventRecorder,
+	)
+	csr.controllers = append(csr.controllers, storageAlertsController)
+
+	storageCapacityController := storagecapacity.NewController(
+		csr.commonClients,
+		resync,
+		csr.eventRecorder,
+	)
+	csr.controllers = append(csr.controllers, storageCapacityController)
+
 	relatedObjects := []configv1.ObjectReference{
 		{Resource: "namespaces", Name: operatorNamespace},
 		{Resource: "namespaces", Name: csoclients.CSIOperatorNamespace},
@@ -137,7 +156,11 @@ func (csr *commonStarter) CreateCommonControllers() error {
 	// This controller removes VSphereProblemDetectorDeploymentControllerAvailable condition in the storage status
 	staleConditionsController := staleconditions.NewRemoveStaleConditionsController(
 		"RemoveStaleConditionsController",
-		[]string{"VSphereProblemDetectorDeploymentControllerAvailable"},
+		[]string{
+			"VSphereProblemDetectorDeploymentControllerAvailable",
+			// Produced by the Manila CA certificate syncer replaced by OpenStackCloudConfigSyncController
+			"ResourceSyncControllerDegraded",
+		},
 		csr.commonClients.OperatorClient,
 		csr.eventRecorder,
 	)
@@ -188,13 +211,18 @@ func (ssr *StandaloneStarter) StartOperator(ctx context.Context) error {
 	}
 
 	if ssr.featureGates.Enabled(features.FeatureGateSELinuxMountGAReadiness) {
-		ssr.controllers = append(ssr.controllers, selinuxmountreadiness.NewController(ssr.commonClients, ssr.eventRecorder))
+		ssr.controllers = append(ssr.controllers, selinuxmountreadiness.NewController(ssr.commonClients, status.VersionForOperatorFromEnv(), ssr.eventRecorder))
+	}
+	ssr.controllers = append(ssr.controllers, admissionpolicy.NewController(ssr.commonClients, ssr.featureGates, resync, ssr.eventRecorder))
+	if ssr.featureGates.Enabled(features.FeatureGateStoragePerformantSecurityPolicy) {
+		ssr.controllers = append(ssr.controllers, performantpolicy.NewController(ssr.commonClients, resync, ssr.eventRecorder))
+		metrics.InitializeNamespacePolicyMetrics(ssr.commonClients)
 	}
 
 	metrics.CountStorageClasses(ssr.commonClients)
 	metrics.InitializeVACMismatchMetrics(ssr.commonClients)
 
-	csiDriverConfigs := ssr.populateConfigs(ssr.commonClients)
+	csiDriverConfigs := ssr.populateConfigs()
 	csiDriverController, _ := csidriveroperator.NewStandaloneDriverStarter(
 		ssr.commonClients,
 		ssr.featureGates,
@@ -204,6 +232,11 @@ func (ssr *StandaloneStarter) StartOperator(ctx context.Context) error {
 		ssr.eventRecorder,
 		csiDriverConfigs)
 	ssr.controllers = append(ssr.controllers, csiDriverController)
+	ssr.controllers = append(ssr.controllers, csidriveroperator.NewCloudConfigSyncControllers(
+		ssr.commonClients,
+		csiDriverConfigs,
+		resync,
+		ssr.eventRecorder)...)
 
 	vsphereProblemDetector := vsphereproblemdetector.NewVSphereProblemDetectorStarter(
 		ssr.commonClients,
@@ -213,6 +246,16 @@ func (ssr *StandaloneStarter) StartOperator(ctx context.Context) error {
 		ssr.eventRecorder)
 	ssr.controllers = append(ssr.controllers, vsphereProblemDetector)
 
+	tlsComplianceController, err := tlscompliance.NewStandaloneController(
+		ssr.commonClients,
+		csiDriverConfigs,
+		resync,
+		ssr.eventRecorder)
+	if err != nil {
+		return err
+	}
+	ssr.controllers = append(ssr.controllers, tlsComplianceController)
+
 	klog.Info("Starting the Informers.")
 
 	csoclients.StartInformers(ssr.commonClients, ctx.Done())
@@ -221,30 +264,32 @@ func (ssr *StandaloneStarter) StartOperator(ctx context.Context) error {
 	return nil
 }
 
-func (ssr *StandaloneStarter) populateConfigs(clients *csoclients.Clients) []csioperatorclient.CSIOperatorConfig {
+func (ssr *StandaloneStarter) populateConfigs() []csioperatorclient.CSIOperatorConfig {
 	return []csioperatorclient.CSIOperatorConfig{
 		csioperatorclient.GetAzureDiskCSIOperatorConfig(false),
 		csioperatorclient.GetAzureFileCSIOperatorConfig(false),
 		csioperatorclient.GetAWSEBSCSIOperatorConfig(false),
-		csioperatorclient.GetGCPPDCSIOperatorConfig(),
-		csioperatorclient.GetIBMVPCBlockCSIOperatorConfig(),
-		csioperatorclient.GetOpenStackManilaOperatorConfig(false, clients, ssr.eventRecorder),
+		csioperatorclient.GetGCPPDCSIOperatorConfig(false),
+		csioperatorclient.GetIBMVPCBlockCSIOperatorConfig(false),
+		csioperatorclient.GetOpenStackManilaOperatorConfig(false),
 		csioperatorclient.GetOpenStackCinderCSIOperatorConfig(false),
 		csioperatorclient.GetPowerVSBlockCSIOperatorConfig(false),
-		csioperatorclient.GetVMwareVSphereCSIOperatorConfig(),
+		csioperatorclient.GetVMwareVSphereCSIOperatorConfig(false),
 	}
 }
 
 type HyperShiftStarter struct {
 	commonStarter
-	guestKubeConfig string
-	mgmtClient      *csoclients.Clients
+	guestKubeConfig        string
+	hostedControlPlaneName string
+	mgmtClient             *csoclients.Clients
 }
 
-func NewHyperShiftStarter(controllerConfig *controllercmd.ControllerContext, guestKubeConfig string) OperatorStarter {
+func NewHyperShiftStarter(controllerConfig *controllercmd.ControllerContext, guestKubeConfig, hostedControlPlaneName string) OperatorStarter {
 	hsr := &HyperShiftStarter{}
 	hsr.controllerConfig = controllerConfig
 	hsr.guestKubeConfig = guestKubeConfig
+	hsr.hostedControlPlaneName = hostedControlPlaneName
 	return hsr
 }
 
@@ -288,7 +333,7 @@ func (hsr *HyperShiftStarter) StartOperator(ctx context.Context) error {
 	}
 
 	controlPlaneNamespace := hsr.controllerConfig.OperatorNamespace
-	csiDriverConfigs := hsr.populateConfigs(hsr.mgmtClient)
+	csiDriverConfigs := hsr.populateConfigs()
 
 	err = hsr.commonStarter.getFeatureGate(ctx)
 	if err != nil {
@@ -296,7 +341,12 @@ func (hsr *HyperShiftStarter) StartOperator(ctx context.Context) error {
 	}
 
 	if hsr.featureGates.Enabled(features.FeatureGateSELinuxMountGAReadiness) {
-		hsr.controllers = append(hsr.controllers, selinuxmountreadiness.NewController(hsr.commonClients, hsr.eventRecorder))
+		hsr.controllers = append(hsr.controllers, selinuxmountreadiness.NewController(hsr.commonClients, status.VersionForOperatorFromEnv(), hsr.eventRecorder))
+	}
+	hsr.controllers = append(hsr.controllers, admissionpolicy.NewController(hsr.commonClients, hsr.featureGates, resync, hsr.eventRecorder))
+	if hsr.featureGates.Enabled(features.FeatureGateStoragePerformantSecurityPolicy) {
+		hsr.controllers = append(hsr.controllers, performantpolicy.NewController(hsr.commonClients, resync, hsr.eventRecorder))
+		metrics.InitializeNamespacePolicyMetrics(hsr.commonClients)
 	}
 
 	metrics.InitializeVACMismatchMetrics(hsr.commonClients)
@@ -306,6 +356,7 @@ func (hsr *HyperShiftStarter) StartOperator(ctx context.Context) error {
 		hsr.mgmtClient,
 		hsr.featureGates,
 		controlPlaneNamespace,
+		hsr.hostedControlPlaneName,
 		resync,
 		hsr.versionGetter,
 		status.VersionForOperandFromEnv(),
@@ -315,6 +366,41 @@ func (hsr *HyperShiftStarter) StartOperator(ctx context.Context) error {
 	)
 
 	hsr.controllers = append(hsr.controllers, csiDriverController)
+
+	teardownController := csidriveroperator.NewHyperShiftTeardownController(
+		hsr.commonClients,
+		hsr.mgmtClient,
+		controlPlaneNamespace,
+		csiDriverConfigs,
+		resync,
+		hsr.eventRecorder,
+	)
+	hsr.controllers = append(hsr.controllers, teardownController)
+
+	statusController := csidriveroperator.NewHyperShiftStatusController(
+		hsr.commonClients,
+		hsr.mgmtClient,
+		controlPlaneNamespace,
+		hsr.hostedControlPlaneName,
+		csiDriverConfigs,
+		resync,
+		hsr.eventRecorder,
+	)
+	hsr.controllers = append(hsr.controllers, statusController)
+
+	tlsComplianceController, err := tlscompliance.NewHyperShiftController(
+		hsr.commonClients,
+		hsr.mgmtClient,
+		controlPlaneNamespace,
+		hsr.hostedControlPlaneName,
+		csiDriverConfigs,
+		resync,
+		hsr.eventRecorder,
+	)
+	if err != nil {
+		return err
+	}
+	hsr.controllers = append(hsr.controllers, tlsComplianceController)
 	klog.Info("Starting the Informers.")
 
 	csoclients.StartGuestInformers(hsr.commonClients, ctx.Done())
@@ -324,13 +410,16 @@ func (hsr *HyperShiftStarter) StartOperator(ctx context.Context) error {
 	return nil
 }
 
-func (hsr *HyperShiftStarter) populateConfigs(clients *csoclients.Clients) []csioperatorclient.CSIOperatorConfig {
+func (hsr *HyperShiftStarter) populateConfigs() []csioperatorclient.CSIOperatorConfig {
 	return []csioperatorclient.CSIOperatorConfig{
 		csioperatorclient.GetAzureDiskCSIOperatorConfig(true),
 		csioperatorclient.GetAzureFileCSIOperatorConfig(true),
 		csioperatorclient.GetAWSEBSCSIOperatorConfig(true),
-		csioperatorclient.GetOpenStackManilaOperatorConfig(true, clients, hsr.eventRecorder),
+		csioperatorclient.GetGCPPDCSIOperatorConfig(true),
+		csioperatorclient.GetIBMVPCBlockCSIOperatorConfig(true),
+		csioperatorclient.GetOpenStackManilaOperatorConfig(true),
 		csioperatorclient.GetOpenStackCinderCSIOperatorConfig(true),
 		csioperatorclient.GetPowerVSBlockCSIOperatorConfig(true),
+		csioperatorclient.GetVMwareVSphereCSIOperatorConfig(true),
 	}
 }
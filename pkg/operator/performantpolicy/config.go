@@ -0,0 +1,101 @@
+package performantpolicy
+
+import (
+	"fmt"
+	"path"
+
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// FSGroupChangePolicyLabel on a namespace sets the default fsGroupChangePolicy of its pods.
+	FSGroupChangePolicyLabel = "storage.openshift.io/fsgroup-change-policy"
+	// SELinuxChangePolicyLabel on a namespace sets the default seLinuxChangePolicy of its pods.
+	SELinuxChangePolicyLabel = "storage.openshift.io/selinux-change-policy"
+
+	// DefaultLabelsAnnotation on a namespace records, as a JSON map, the policy labels set by
+	// the controller from the cluster-wide defaults. Labels not recorded there were set
+	// by someone else and are never changed by the controller.
+	DefaultLabelsAnnotation = "storage.openshift.io/default-policy-labels"
+
+	// configMapName is an optional ConfigMap in the CSO namespace with PolicyConfig.
+	configMapName = "storage-performant-policy"
+)
+
+// policyLabels are the namespace labels managed by the controller.
+var policyLabels = []string{FSGroupChangePolicyLabel, SELinuxChangePolicyLabel}
+
+// platformNamespaces are never labeled, the platform components set their policies explicitly.
+var platformNamespaces = []string{"openshift-*", "kube-*"}
+
+// PolicyConfig sets cluster-wide defaults of the storage performant policy.
+// The Storage CR has no field for them, so they are read from a ConfigMap.
+// Labels set from a previous default are updated or removed when the default changes.
+type PolicyConfig struct {
+	// DefaultFSGroupChangePolicy is set as FSGroupChangePolicyLabel on namespaces without the label.
+	DefaultFSGroupChangePolicy corev1.PodFSGroupChangePolicy `yaml:"defaultFSGroupChangePolicy,omitempty"`
+	// DefaultSELinuxChangePolicy is set as SELinuxChangePolicyLabel on namespaces without the label.
+	DefaultSELinuxChangePolicy corev1.PodSELinuxChangePolicy `yaml:"defaultSELinuxChangePolicy,omitempty"`
+	// ExcludedNamespaces are glob patterns of namespaces that are not labeled,
+	// in addition to openshift-* and kube-*.
+	ExcludedNamespaces []string `yaml:"excludedNamespaces,omitempty"`
+}
+
+// parseConfigMap returns the policy config from the storage-performant-policy ConfigMap.
+// Missing ConfigMap means no defaults.
+func parseConfigMap(lister listerv1.ConfigMapLister) (*PolicyConfig, error) {
+	config := &PolicyConfig{}
+	if _, err := csoutils.ParseOperatorConfigMap(lister, configMapName, config); err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config in ConfigMap %s: %s", configMapName, err)
+	}
+	return config, nil
+}
+
+// validate accepts the same values as openshift-storage-policy-validation ValidatingAdmissionPolicy.
+func (c *PolicyConfig) validate() error {
+	switch c.DefaultFSGroupChangePolicy {
+	case "", corev1.FSGroupChangeAlways, corev1.FSGroupChangeOnRootMismatch:
+	default:
+		return fmt.Errorf("invalid defaultFSGroupChangePolicy %q, expected %s or %s",
+			c.DefaultFSGroupChangePolicy, corev1.FSGroupChangeAlways, corev1.FSGroupChangeOnRootMismatch)
+	}
+	switch c.DefaultSELinuxChangePolicy {
+	case "", corev1.SELinuxChangePolicyRecursive, corev1.SELinuxChangePolicyMountOption:
+	default:
+		return fmt.Errorf("invalid defaultSELinuxChangePolicy %q, expected %s or %s",
+			c.DefaultSELinuxChangePolicy, corev1.SELinuxChangePolicyRecursive, corev1.SELinuxChangePolicyMountOption)
+	}
+	for _, pattern := range c.ExcludedNamespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid excludedNamespaces pattern %q: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+// defaultLabels returns the labels to set on namespaces that do not have them.
+func (c *PolicyConfig) defaultLabels() map[string]string {
+	labels := map[string]string{}
+	if c.DefaultFSGroupChangePolicy != "" {
+		labels[FSGroupChangePolicyLabel] = string(c.DefaultFSGroupChangePolicy)
+	}
+	if c.DefaultSELinuxChangePolicy != "" {
+		labels[SELinuxChangePolicyLabel] = string(c.DefaultSELinuxChangePolicy)
+	}
+	return labels
+}
+
+func (c *PolicyConfig) isExcluded(namespace string) bool {
+	for _, pattern := range append(platformNamespaces, c.ExcludedNamespaces...) {
+		// Patterns were validated in parseConfigMap
+		if match, _ := path.Match(pattern, namespace); match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package performantpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"sort"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const controllerName = "StoragePerformantPolicyController"
+
+// Controller labels namespaces that have no storage performant policy labels with
+// the cluster-wide defaults from the storage-performant-policy ConfigMap.
+// The labels it sets are recorded in DefaultLabelsAnnotation and follow the defaults:
+// they are updated when a default changes and removed when it is removed or the namespace
+// is excluded. Labels set by anyone else are never changed.
+type Controller struct {
+	operatorClient  v1helpers.OperatorClient
+	kubeClient      kubernetes.Interface
+	configMapLister listerv1.ConfigMapLister
+	namespaceLister listerv1.NamespaceLister
+	eventRecorder   events.Recorder
+}
+
+func NewController(
+	clients *csoclients.Clients,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+	c := &Controller{
+		operatorClient:  clients.OperatorClient,
+		kubeClient:      clients.KubeClient,
+		configMapLister: clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+		namespaceLister: clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Lister(),
+		eventRecorder:   eventRecorder.WithComponentSuffix("storage-performant-policy-controller"),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			c.operatorClient.Informer(),
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+			clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(controllerName, c.eventRecorder)
+}
+
+func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("StoragePerformantPolicyController sync started")
+	defer klog.V(4).Infof("StoragePerformantPolicyController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	cfg, err := parseConfigMap(c.configMapLister)
+	if err != nil {
+		return err
+	}
+	defaults := cfg.defaultLabels()
+
+	namespaces, err := c.namespaceLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var updated []string
+	var errs []error
+	for _, ns := range namespaces {
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		nsDefaults := defaults
+		if cfg.isExcluded(ns.Name) {
+			nsDefaults = nil
+		}
+		changed, applied := namespaceLabels(ns, nsDefaults)
+		if len(changed) == 0 && maps.Equal(applied, appliedDefaults(ns)) {
+			continue
+		}
+		if err := c.patchNamespace(ctx, ns.Name, changed, applied); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated = append(updated, ns.Name)
+	}
+
+	if len(updated) > 0 {
+		// One event per sync, there may be thousands of namespaces
+		sort.Strings(updated)
+		c.eventRecorder.Eventf("NamespacesLabeled", "Updated labels of %d namespaces to default storage performant policy %v, e.g. %s",
+			len(updated), defaults, updated[0])
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// namespaceLabels returns policy labels of the namespace to change, with nil value for labels
+// to remove, and the labels that are set from the defaults after the change.
+// A label is owned by the controller only while it has the value recorded in DefaultLabelsAnnotation.
+func namespaceLabels(ns *corev1.Namespace, defaults map[string]string) (map[string]*string, map[string]string) {
+	previous := appliedDefaults(ns)
+	changed := map[string]*string{}
+	applied := map[string]string{}
+	for _, key := range policyLabels {
+		current, labeled := ns.Labels[key]
+		value, hasDefault := defaults[key]
+		owned := labeled && previous[key] == current
+		switch {
+		case labeled && !owned:
+			// Set by the user or by SELinuxMount remediation
+			continue
+		case hasDefault:
+			if current != value {
+				changed[key] = &value
+			}
+			applied[key] = value
+		case labeled:
+			// The default was removed
+			changed[key] = nil
+		}
+	}
+	return changed, applied
+}
+
+// appliedDefaults returns the policy labels recorded in DefaultLabelsAnnotation of the namespace.
+func appliedDefaults(ns *corev1.Namespace) map[string]string {
+	applied := map[string]string{}
+	value, found := ns.Annotations[DefaultLabelsAnnotation]
+	if !found {
+		return applied
+	}
+	if err := json.Unmarshal([]byte(value), &applied); err != nil {
+		// Treat all labels as set by the user, the annotation is rewritten on the next change
+		klog.Warningf("Failed to parse annotation %s of namespace %s: %s", DefaultLabelsAnnotation, ns.Name, err)
+		return map[string]string{}
+	}
+	return applied
+}
+
+// IsDefaultLabel returns true when the policy label of the namespace was set by the controller
+// from a cluster-wide default, i.e. it was not chosen for the namespace explicitly.
+func IsDefaultLabel(ns *corev1.Namespace, key string) bool {
+	value, labeled := ns.Labels[key]
+	if !labeled {
+		return false
+	}
+	applied, found := appliedDefaults(ns)[key]
+	return found && applied == value
+}
+
+func (c *Controller) patchNamespace(ctx context.Context, name string, changed map[string]*string, applied map[string]string) error {
+	var annotation *string
+	if len(applied) > 0 {
+		data, err := json.Marshal(applied)
+		if err != nil {
+			return err
+		}
+		value := string(data)
+		annotation = &value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      changed,
+			"annotations": map[string]*string{DefaultLabelsAnnotation: annotation},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("Setting policy labels of namespace %s to %s", name, patch)
+	_, err = c.kubeClient.CoreV1().Namespaces().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package performantpolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func getCM(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+func getNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+}
+
+// withDefaultLabels records the labels as set by the controller from the defaults.
+func withDefaultLabels(ns *corev1.Namespace, applied string) *corev1.Namespace {
+	ns.Annotations = map[string]string{DefaultLabelsAnnotation: applied}
+	return ns
+}
+
+func TestController(t *testing.T) {
+	const (
+		onRootMismatch = `{"storage.openshift.io/fsgroup-change-policy":"OnRootMismatch"}`
+		always         = `{"storage.openshift.io/fsgroup-change-policy":"Always"}`
+	)
+	namespaces := []runtime.Object{
+		getNamespace("app1", nil),
+		getNamespace("app2", map[string]string{FSGroupChangePolicyLabel: "Always"}),
+		getNamespace("openshift-monitoring", nil),
+		getNamespace("kube-system", nil),
+		getNamespace("team-a-dev", nil),
+		// Labeled by the controller from a previous default
+		withDefaultLabels(getNamespace("app3", map[string]string{FSGroupChangePolicyLabel: "OnRootMismatch"}), onRootMismatch),
+		// Labeled by the controller, then changed by the user
+		withDefaultLabels(getNamespace("app4", map[string]string{FSGroupChangePolicyLabel: "Always"}), onRootMismatch),
+	}
+
+	tests := []struct {
+		name           string
+		configMap      *corev1.ConfigMap
+		expectErr      string
+		expectedLabels map[string]map[string]string
+		// expectedAnnotations are values of DefaultLabelsAnnotation, empty when the annotation is not expected
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "no config removes labels set from defaults",
+			expectedLabels: map[string]map[string]string{
+				"app1":                 nil,
+				"app2":                 {FSGroupChangePolicyLabel: "Always"},
+				"openshift-monitoring": nil,
+				"app3":                 nil,
+				"app4":                 {FSGroupChangePolicyLabel: "Always"},
+			},
+			expectedAnnotations: map[string]string{
+				"app1": "",
+				"app3": "",
+				"app4": "",
+			},
+		},
+		{
+			name:      "default fsGroupChangePolicy",
+			configMap: getCM("defaultFSGroupChangePolicy: OnRootMismatch\n"),
+			expectedLabels: map[string]map[string]string{
+				"app1":                 {FSGroupChangePolicyLabel: "OnRootMismatch"},
+				"app2":                 {FSGroupChangePolicyLabel: "Always"},
+				"openshift-monitoring": nil,
+				"kube-system":          nil,
+				"team-a-dev":           {FSGroupChangePolicyLabel: "OnRootMismatch"},
+				"app3":                 {FSGroupChangePolicyLabel: "OnRootMismatch"},
+				"app4":                 {FSGroupChangePolicyLabel: "Always"},
+			},
+			expectedAnnotations: map[string]string{
+				"app1":                 onRootMismatch,
+				"app2":                 "",
+				"openshift-monitoring": "",
+				"app3":                 onRootMismatch,
+				"app4":                 "",
+			},
+		},
+		{
+			name:      "changed default updates labels set from defaults",
+			configMap: getCM("defaultFSGroupChangePolicy: Always\n"),
+			expectedLabels: map[string]map[string]string{
+				"app1": {FSGroupChangePolicyLabel: "Always"},
+				"app2": {FSGroupChangePolicyLabel: "Always"},
+				"app3": {FSGroupChangePolicyLabel: "Always"},
+			},
+			expectedAnnotations: map[string]string{
+				"app1": always,
+				"app2": "",
+				"app3": always,
+			},
+		},
+		{
+			name:      "excluded namespace loses labels set from defaults",
+			configMap: getCM("defaultFSGroupChangePolicy: OnRootMismatch\nexcludedNamespaces: [\"app3\"]\n"),
+			expectedLabels: map[string]map[string]string{
+				"app1": {FSGroupChangePolicyLabel: "OnRootMismatch"},
+				"app3": nil,
+			},
+			expectedAnnotations: map[string]string{
+				"app3": "",
+			},
+		},
+		{
+			name: "both defaults with exclusions",
+			configMap: getCM(`
+defaultFSGroupChangePolicy: OnRootMismatch
+defaultSELinuxChangePolicy: Recursive
+excludedNamespaces: ["team-*"]
+`),
+			expectedLabels: map[string]map[string]string{
+				"app1":                 {FSGroupChangePolicyLabel: "OnRootMismatch", SELinuxChangePolicyLabel: "Recursive"},
+				"app2":                 {FSGroupChangePolicyLabel: "Always", SELinuxChangePolicyLabel: "Recursive"},
+				"openshift-monitoring": nil,
+				"team-a-dev":           nil,
+				"app3":                 {FSGroupChangePolicyLabel: "OnRootMismatch", SELinuxChangePolicyLabel: "Recursive"},
+			},
+			expectedAnnotations: map[string]string{
+				"app2": `{"storage.openshift.io/selinux-change-policy":"Recursive"}`,
+				"app3": `{"storage.openshift.io/fsgroup-change-policy":"OnRootMismatch","storage.openshift.io/selinux-change-policy":"Recursive"}`,
+			},
+		},
+		{
+			name:      "invalid policy",
+			configMap: getCM("defaultFSGroupChangePolicy: Never\n"),
+			expectErr: `invalid defaultFSGroupChangePolicy "Never"`,
+		},
+		{
+			name:      "invalid pattern",
+			configMap: getCM("excludedNamespaces: [\"team-[\"]\n"),
+			expectErr: `invalid excludedNamespaces pattern "team-["`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coreObjects := append([]runtime.Object{}, namespaces...)
+			if tt.configMap != nil {
+				coreObjects = append(coreObjects, tt.configMap)
+			}
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     coreObjects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+			})
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewController(clients, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctrl.Sync(context.TODO(), nil)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expected error %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, expected := range tt.expectedLabels {
+				ns, err := clients.KubeClient.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get namespace %s: %v", name, err)
+				}
+				if len(ns.Labels) != len(expected) {
+					t.Errorf("namespace %s: expected labels %v, got %v", name, expected, ns.Labels)
+					continue
+				}
+				for key, value := range expected {
+					if ns.Labels[key] != value {
+						t.Errorf("namespace %s: expected labels %v, got %v", name, expected, ns.Labels)
+					}
+				}
+			}
+
+			for name, expected := range tt.expectedAnnotations {
+				ns, err := clients.KubeClient.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get namespace %s: %v", name, err)
+				}
+				if got := ns.Annotations[DefaultLabelsAnnotation]; got != expected {
+					t.Errorf("namespace %s: expected annotation %s %q, got %q", name, DefaultLabelsAnnotation, expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package problemdetector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// Every pod in OpenShift gets the service CA bundle, which signed the detector serving cert.
+	serviceCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt"
+	tokenFile     = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// checksController scrapes metrics of the detector and summarizes its failed checks
+// in <ConditionPrefix>ChecksFailing condition, so they're visible also in clusters
+// without cluster monitoring.
+type checksController struct {
+	detector       *Detector
+	operatorClient v1helpers.OperatorClient
+	// newHTTPClient returns a client that trusts the detector serving cert.
+	newHTTPClient func() (*http.Client, error)
+	metricsURL    string
+	tokenFile     string
+}
+
+func newChecksController(
+	detector *Detector,
+	clients *csoclients.Clients,
+	eventRecorder events.Recorder,
+	resyncInterval time.Duration) (factory.Controller, error) {
+
+	metricsURL, serverName, err := metricsEndpoint(detector.MetricsServiceAsset)
+	if err != nil {
+		return nil, err
+	}
+	c := &checksController{
+		detector:       detector,
+		operatorClient: clients.OperatorClient,
+		newHTTPClient: func() (*http.Client, error) {
+			return serviceCAHTTPClient(serverName)
+		},
+		metricsURL: metricsURL,
+		tokenFile:  tokenFile,
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithInformers(c.operatorClient.Informer()).
+		ResyncEvery(resyncInterval).
+		ToController(detector.checksControllerName(), eventRecorder), nil
+}
+
+func (c *checksController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("%s sync started", c.detector.checksControllerName())
+	defer klog.V(4).Infof("%s sync finished", c.detector.checksControllerName())
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	cond := operatorapi.OperatorCondition{
+		Type:   c.detector.checksConditionType(),
+		Status: operatorapi.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	failed, err := c.failedChecks(ctx)
+	switch {
+	case err != nil:
+		// The detector may not be running yet, try again in the next sync.
+		klog.V(2).Infof("Failed to get %s metrics: %v", c.detector.Name, err)
+		cond.Status = operatorapi.ConditionUnknown
+		cond.Reason = "MetricsUnavailable"
+		cond.Message = fmt.Sprintf("Failed to get %s metrics: %v", c.detector.Name, err)
+	case len(failed) > 0:
+		cond.Status = operatorapi.ConditionTrue
+		cond.Reason = "ChecksFailed"
+		cond.Message = fmt.Sprintf("%s checks are failing: %s. To get details about the failures, "+
+			"see events in namespace %s.", c.detector.DisplayName, strings.Join(failed, ", "), csoclients.OperatorNamespace)
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond))
+	return err
+}
+
+// failedChecks returns sorted descriptions of the failed checks. Node checks include
+// names of the failed nodes.
+func (c *checksController) failedChecks(ctx context.Context) ([]string, error) {
+	body, err := c.scrape(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	var failed []string
+	for _, metric := range families[c.detector.ClusterCheckMetric].GetMetric() {
+		if metric.GetGauge().GetValue() == 1 {
+			failed = append(failed, labelValue(metric, "check"))
+		}
+	}
+	nodes := map[string][]string{}
+	for _, metric := range families[c.detector.NodeCheckMetric].GetMetric() {
+		if metric.GetGauge().GetValue() != 1 {
+			continue
+		}
+		check, node := labelValue(metric, "check"), labelValue(metric, "node")
+		nodes[check] = append(nodes[check], node)
+	}
+	for check, checkNodes := range nodes {
+		slices.Sort(checkNodes)
+		failed = append(failed, fmt.Sprintf("%s (nodes %s)", check, strings.Join(checkNodes, ", ")))
+	}
+	slices.Sort(failed)
+	return slices.Compact(failed), nil
+}
+
+func (c *checksController) scrape(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metricsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.tokenFile != "" {
+		// Read the token on each scrape, it's rotated by kubelet.
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	client, err := c.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response from %s: %s", c.metricsURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+// metricsEndpoint returns URL of the detector metrics Service and the server name in its serving cert.
+func metricsEndpoint(metricsServiceAsset string) (string, string, error) {
+	serviceBytes, err := assets.ReadFile(metricsServiceAsset)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read asset %s: %w", metricsServiceAsset, err)
+	}
+	service := &corev1.Service{}
+	if err := sigsyaml.Unmarshal(serviceBytes, service); err != nil {
+		return "", "", fmt.Errorf("failed to decode asset %s: %w", metricsServiceAsset, err)
+	}
+	if len(service.Spec.Ports) == 0 {
+		return "", "", fmt.Errorf("asset %s has no ports", metricsServiceAsset)
+	}
+	serverName := fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace)
+	return fmt.Sprintf("https://%s:%d/metrics", serverName, service.Spec.Ports[0].Port), serverName, nil
+}
+
+// serviceCAHTTPClient returns a client that verifies the server with the service CA. The CA is
+// loaded on each call, because it's rotated by service-ca-operator.
+func serviceCAHTTPClient(serverName string) (*http.Client, error) {
+	caBytes, err := os.ReadFile(serviceCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", serviceCAFile)
+	}
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			// The client is used only once per sync
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				RootCAs:    roots,
+				ServerName: serverName,
+			},
+		},
+	}, nil
+}
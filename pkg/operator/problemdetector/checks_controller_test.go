@@ -0,0 +1,135 @@
+package problemdetector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const detectorMetrics = `# HELP vsphere_cluster_check_errors Number of failed vSphere cluster-level checks performed by vsphere-problem-detector.
+# TYPE vsphere_cluster_check_errors gauge
+vsphere_cluster_check_errors{check="CheckDefaultDatastore"} 1
+vsphere_cluster_check_errors{check="CheckFolderPermissions"} 0
+vsphere_cluster_check_errors{check="CheckTaskPermissions"} 1
+# HELP vsphere_node_check_errors Number of failed vSphere node-level checks performed by vsphere-problem-detector.
+# TYPE vsphere_node_check_errors gauge
+vsphere_node_check_errors{check="CheckNodeDiskUUID",node="worker-b"} 1
+vsphere_node_check_errors{check="CheckNodeDiskUUID",node="worker-a"} 1
+vsphere_node_check_errors{check="CheckNodeProviderID",node="worker-a"} 0
+# HELP vsphere_vcenter_info Information about vSphere vCenter.
+# TYPE vsphere_vcenter_info gauge
+vsphere_vcenter_info{api_version="8.0.2",uuid="foo",version="8.0.2"} 1
+`
+
+const passingDetectorMetrics = `# TYPE vsphere_cluster_check_errors gauge
+vsphere_cluster_check_errors{check="CheckDefaultDatastore"} 0
+# TYPE vsphere_node_check_errors gauge
+vsphere_node_check_errors{check="CheckNodeDiskUUID",node="worker-a"} 0
+`
+
+func TestChecksController(t *testing.T) {
+	tests := []struct {
+		name            string
+		metrics         string
+		statusCode      int
+		expectedStatus  opv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "passing checks",
+			metrics:        passingDetectorMetrics,
+			statusCode:     http.StatusOK,
+			expectedStatus: opv1.ConditionFalse,
+			expectedReason: "AsExpected",
+		},
+		{
+			name:           "failing checks",
+			metrics:        detectorMetrics,
+			statusCode:     http.StatusOK,
+			expectedStatus: opv1.ConditionTrue,
+			expectedReason: "ChecksFailed",
+			expectedMessage: "vSphere checks are failing: CheckDefaultDatastore, CheckNodeDiskUUID (nodes worker-a, worker-b), CheckTaskPermissions. " +
+				"To get details about the failures, see events in namespace openshift-cluster-storage-operator.",
+		},
+		{
+			name:           "detector not ready",
+			statusCode:     http.StatusServiceUnavailable,
+			expectedStatus: opv1.ConditionUnknown,
+			expectedReason: "MetricsUnavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer test-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.metrics))
+			}))
+			defer server.Close()
+
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(tokenFile, []byte("test-token\n"), 0600); err != nil {
+				t.Fatalf("failed to write token: %v", err)
+			}
+
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+			})
+			c := &checksController{
+				detector:       testDetector,
+				operatorClient: clients.OperatorClient,
+				newHTTPClient:  func() (*http.Client, error) { return server.Client(), nil },
+				metricsURL:     server.URL + "/metrics",
+				tokenFile:      tokenFile,
+			}
+
+			if err := c.sync(context.TODO(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, status, _, err := clients.OperatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("failed to get Storage: %v", err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, testDetector.checksConditionType())
+			if cond == nil {
+				t.Fatalf("%s condition not found", testDetector.checksConditionType())
+			}
+			if cond.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, cond.Status)
+			}
+			if cond.Reason != tt.expectedReason {
+				t.Errorf("expected reason %s, got %s", tt.expectedReason, cond.Reason)
+			}
+			if tt.expectedMessage != "" && cond.Message != tt.expectedMessage {
+				t.Errorf("unexpected message:\nexpected: %s\ngot:      %s", tt.expectedMessage, cond.Message)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	url, serverName, err := metricsEndpoint(testDetector.MetricsServiceAsset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "https://vsphere-problem-detector-metrics.openshift-cluster-storage-operator.svc:8444/metrics"; url != expected {
+		t.Errorf("expected URL %s, got %s", expected, url)
+	}
+	if expected := "vsphere-problem-detector-metrics.openshift-cluster-storage-operator.svc"; serverName != expected {
+		t.Errorf("expected server name %s, got %s", expected, serverName)
+	}
+}
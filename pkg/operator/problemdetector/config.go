@@ -0,0 +1,92 @@
+package problemdetector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// DetectorConfig is consumed only by the operator, to template the detector alerts.
+// The detector itself does not read it and runs all checks with its defaults.
+type DetectorConfig struct {
+	AlertsDisabled bool `yaml:"alertsDisabled,omitempty"`
+	// AlertSuppressedChecks are names of checks (e.g. CheckDefaultDatastore) whose failures
+	// do not fire alerts. The checks still run and report their metrics.
+	AlertSuppressedChecks []string `yaml:"alertSuppressedChecks,omitempty"`
+	// AlertSeverities overrides severity of alerts, indexed by the alert name.
+	AlertSeverities map[string]string `yaml:"alertSeverities,omitempty"`
+	// MaintenanceWindow suppresses all alerts while the platform is under maintenance.
+	MaintenanceWindow *MaintenanceWindow `yaml:"maintenanceWindow,omitempty"`
+}
+
+type MaintenanceWindow struct {
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+}
+
+var (
+	defaultConfig = DetectorConfig{
+		// Alerts are enabled by default
+		AlertsDisabled: false,
+	}
+
+	// Check names are used in PromQL label matchers, restrict them to safe characters.
+	checkNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+	validSeverities = map[string]bool{
+		"critical": true,
+		"warning":  true,
+		"info":     true,
+	}
+)
+
+// ParseConfigMap parses detector config from ConfigMap with the given name.
+func ParseConfigMap(lister listerv1.ConfigMapLister, detectorConfigMapName string) (*DetectorConfig, error) {
+	config := defaultConfig
+	found, err := csoutils.ParseOperatorConfigMap(lister, detectorConfigMapName, &config)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &defaultConfig, nil
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config in ConfigMap %s: %s", detectorConfigMapName, err)
+	}
+	klog.V(4).Infof("Parsed ConfigMap %s: %+v", detectorConfigMapName, config)
+	return &config, nil
+}
+
+func (c *DetectorConfig) validate() error {
+	for _, name := range c.AlertSuppressedChecks {
+		if !checkNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid check name %q in alertSuppressedChecks", name)
+		}
+	}
+	for alert, severity := range c.AlertSeverities {
+		if !validSeverities[severity] {
+			return fmt.Errorf("invalid severity %q of alert %s, expected one of critical, warning, info", severity, alert)
+		}
+	}
+	if w := c.MaintenanceWindow; w != nil {
+		if w.Start.IsZero() || w.End.IsZero() {
+			return fmt.Errorf("maintenanceWindow must have both start and end")
+		}
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("maintenanceWindow end must be after its start")
+		}
+	}
+	return nil
+}
+
+// suppressedChecks returns sorted names of the checks with suppressed alerts.
+func (c *DetectorConfig) suppressedChecks() []string {
+	checks := append([]string(nil), c.AlertSuppressedChecks...)
+	sort.Strings(checks)
+	return checks
+}
@@ -0,0 +1,103 @@
+package problemdetector
+
+import (
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorapi "github.com/openshift/api/operator/v1"
+)
+
+// Detector describes a platform problem detector: an operand that runs on a single platform,
+// checks its configuration and reports the problems as metrics and alerts.
+type Detector struct {
+	// Platform on which the detector runs. The detector is not deployed on any other platform.
+	Platform configv1.PlatformType
+	// DisplayName is the human readable name of the platform used in condition messages, e.g. "vSphere".
+	DisplayName string
+	// Name of the detector, used in condition messages and logs, e.g. "vsphere-problem-detector".
+	Name string
+	// ConditionPrefix is the prefix of all controllers and conditions of the detector.
+	ConditionPrefix string
+	// ImageEnvVar is the name of the CSO env. var with the detector image.
+	ImageEnvVar string
+
+	// StaticAssets are applied as they are, before the Deployment.
+	StaticAssets []string
+	// DeploymentAsset is the detector Deployment. ${OPERATOR_IMAGE} and ${LOG_LEVEL} are replaced in it.
+	DeploymentAsset string
+	DeploymentName  string
+	// OperatorConfigAsset is the ConfigMap with the detector TLS settings. CSO fills it from APIServer/cluster.
+	OperatorConfigAsset string
+	OperatorConfigName  string
+	// MetricsServiceAsset is the Service of the detector metrics. It must be in StaticAssets too.
+	MetricsServiceAsset string
+	ServiceMonitorAsset string
+	PrometheusRuleAsset string
+
+	// ConfigMapName is the name of optional ConfigMap with the detector configuration, see DetectorConfig.
+	ConfigMapName string
+	// CredentialsSecretName is the Secret with the platform credentials. The detector is restarted when it changes.
+	CredentialsSecretName string
+	// MetricsCertSecretName is the Secret with the detector serving cert.
+	MetricsCertSecretName string
+	// CSIDriverName is the name of ClusterCSIDriver of the platform. Alerts are removed when the CSI driver
+	// is Removed. Empty CSIDriverName means the alerts do not depend on a CSI driver.
+	CSIDriverName string
+
+	// ClusterCheckMetric and NodeCheckMetric are gauges of the detector with a "check" label,
+	// NodeCheckMetric has also a "node" label. Value 1 means the check failed.
+	ClusterCheckMetric string
+	NodeCheckMetric    string
+	// RecheckAnnotation on the Storage CR requests an immediate re-run of all detector checks.
+	RecheckAnnotation string
+}
+
+func (d *Detector) starterName() string {
+	return d.ConditionPrefix + "Starter"
+}
+
+func (d *Detector) staticControllerName() string {
+	return d.ConditionPrefix + "StarterStaticController"
+}
+
+func (d *Detector) deploymentControllerName() string {
+	return d.ConditionPrefix + "DeploymentController"
+}
+
+func (d *Detector) monitoringControllerName() string {
+	return d.ConditionPrefix + "MonitoringController"
+}
+
+func (d *Detector) checksControllerName() string {
+	return d.ConditionPrefix + "ChecksController"
+}
+
+// checksConditionType does not end with Degraded on purpose: failed checks report problems
+// of the platform and they must not make the storage ClusterOperator degraded.
+func (d *Detector) checksConditionType() string {
+	return d.ConditionPrefix + "ChecksFailing"
+}
+
+func (d *Detector) recheckConditionType() string {
+	return d.ConditionPrefix + "Recheck"
+}
+
+func (d *Detector) monitoringConditionType() string {
+	return d.monitoringControllerName() + operatorapi.OperatorStatusTypeAvailable
+}
+
+// checkMetrics returns metrics of the detector that have the check label.
+func (d *Detector) checkMetrics() []string {
+	var metrics []string
+	for _, metric := range []string{d.NodeCheckMetric, d.ClusterCheckMetric} {
+		if metric != "" {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+// eventComponent returns a short platform name for event sources, e.g. "vsphere".
+func (d *Detector) eventComponent() string {
+	return strings.ToLower(string(d.Platform))
+}
@@ -0,0 +1,19 @@
+package problemdetector
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// testDetector uses assets of vsphere-problem-detector.
+var testDetector = &Detector{
+	Platform:            configv1.VSpherePlatformType,
+	DisplayName:         "vSphere",
+	Name:                "vsphere-problem-detector",
+	ConditionPrefix:     "VSphereProblemDetector",
+	MetricsServiceAsset: "vsphere_problem_detector/10_service.yaml",
+	PrometheusRuleAsset: "vsphere_problem_detector/12_prometheusrules.yaml",
+	ConfigMapName:       "vsphere-problem-detector",
+	ClusterCheckMetric:  "vsphere_cluster_check_errors",
+	NodeCheckMetric:     "vsphere_node_check_errors",
+	RecheckAnnotation:   "storage.openshift.io/vsphere-recheck",
+}
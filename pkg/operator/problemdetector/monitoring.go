@@ -1,36 +1,33 @@
-package vsphereproblemdetector
+package problemdetector
 
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	operatorapi "github.com/openshift/api/operator/v1"
 	ov1 "github.com/openshift/client-go/operator/listers/operator/v1"
 	"github.com/openshift/cluster-storage-operator/assets"
 	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
-	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/prometheusrule"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
-	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	promclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
-	promscheme "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/scheme"
-	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	v1 "k8s.io/client-go/listers/core/v1"
-	"k8s.io/klog/v2"
 )
 
 type monitoringController struct {
+	detector         *Detector
 	operatorClient   v1helpers.OperatorClient
 	kubeClient       kubernetes.Interface
 	dynamicClient    dynamic.Interface
@@ -41,34 +38,19 @@ type monitoringController struct {
 	clusterCSIDriverLister ov1.ClusterCSIDriverLister
 }
 
-const (
-	monitoringControllerName = "VSphereProblemDetectorMonitoringController"
-	prometheusRuleFile       = "vsphere_problem_detector/12_prometheusrules.yaml"
-)
-
-var (
-	genericScheme = runtime.NewScheme()
-	genericCodecs = serializer.NewCodecFactory(genericScheme)
-	genericCodec  = genericCodecs.UniversalDeserializer()
-)
-
-func init() {
-	if err := promscheme.AddToScheme(genericScheme); err != nil {
-		panic(err)
-	}
-}
-
 func newMonitoringController(
+	detector *Detector,
 	clients *csoclients.Clients,
 	eventRecorder events.Recorder,
 	resyncInterval time.Duration) factory.Controller {
 
 	c := &monitoringController{
+		detector:         detector,
 		operatorClient:   clients.OperatorClient,
 		kubeClient:       clients.KubeClient,
 		dynamicClient:    clients.DynamicClient,
 		configMapLister:  clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
-		eventRecorder:    eventRecorder.WithComponentSuffix("vsphere-monitoring-controller"),
+		eventRecorder:    eventRecorder.WithComponentSuffix(detector.eventComponent() + "-monitoring-controller"),
 		monitoringClient: clients.MonitoringClient,
 
 		clusterCSIDriverLister: clients.OperatorInformers.Operator().V1().ClusterCSIDrivers().Lister(),
@@ -84,7 +66,7 @@ func newMonitoringController(
 			clients.OperatorInformers.Operator().V1().ClusterCSIDrivers().Informer()).
 		ResyncEvery(resyncInterval).
 		WithSyncDegradedOnError(clients.OperatorClient).
-		ToController(monitoringControllerName, c.eventRecorder)
+		ToController(detector.monitoringControllerName(), c.eventRecorder)
 }
 
 func (c *monitoringController) sync(ctx context.Context, syncContext factory.SyncContext) error {
@@ -99,7 +81,7 @@ func (c *monitoringController) sync(ctx context.Context, syncContext factory.Syn
 	if opSpec.ManagementState != operatorapi.Managed {
 		return nil
 	}
-	smBytes, err := assets.ReadFile("vsphere_problem_detector/11_service_monitor.yaml")
+	smBytes, err := assets.ReadFile(c.detector.ServiceMonitorAsset)
 	if err != nil {
 		return err
 	}
@@ -109,38 +91,38 @@ func (c *monitoringController) sync(ctx context.Context, syncContext factory.Syn
 		return err
 	}
 
-	cfg, err := ParseConfigMap(c.configMapLister)
+	cfg, err := ParseConfigMap(c.configMapLister, c.detector.ConfigMapName)
 	if err != nil {
 		return err
 	}
 
-	ccd, err := c.clusterCSIDriverLister.Get(csioperatorclient.VMwareVSphereDriverName)
+	csiDriverRemoved, err := c.csiDriverRemoved()
 	if err != nil {
 		return err
 	}
 
-	prometheusRuleBytes, err := assets.ReadFile(prometheusRuleFile)
+	prometheusRuleBytes, err := assets.ReadFile(c.detector.PrometheusRuleAsset)
 	if err != nil {
 		return err
 	}
 
 	var message string
-	if cfg.AlertsDisabled || ccd.Spec.OperatorSpec.ManagementState == operatorapi.Removed {
+	if cfg.AlertsDisabled || csiDriverRemoved {
 		err = c.deletePrometheusRule(ctx, prometheusRuleBytes)
 		if err != nil {
 			return err
 		}
-		message = "vsphere-problem-detector alerts are disabled"
+		message = c.detector.Name + " alerts are disabled"
 	} else {
-		_, _, err = c.syncPrometheusRule(ctx, prometheusRuleBytes)
+		_, _, err = c.syncPrometheusRule(ctx, prometheusRuleBytes, cfg)
 		if err != nil {
 			return err
 		}
-		message = "vsphere-problem-detector alerts are enabled"
+		message = c.detector.Name + " alerts are enabled"
 	}
 
 	monitoringCondition := operatorapi.OperatorCondition{
-		Type:    monitoringControllerName + operatorapi.OperatorStatusTypeAvailable,
+		Type:    c.detector.monitoringConditionType(),
 		Status:  operatorapi.ConditionTrue,
 		Message: message,
 	}
@@ -152,40 +134,27 @@ func (c *monitoringController) sync(ctx context.Context, syncContext factory.Syn
 	return nil
 }
 
-func (c *monitoringController) syncPrometheusRule(ctx context.Context, prometheusRuleBytes []byte) (*promv1.PrometheusRule, bool, error) {
-	prometheusRule, err := c.parsePrometheusRule(prometheusRuleBytes)
+// csiDriverRemoved returns true when the CSI driver of the detector platform is Removed.
+func (c *monitoringController) csiDriverRemoved() (bool, error) {
+	if c.detector.CSIDriverName == "" {
+		return false, nil
+	}
+	ccd, err := c.clusterCSIDriverLister.Get(c.detector.CSIDriverName)
 	if err != nil {
-		return prometheusRule, false, err
+		return false, err
 	}
+	return ccd.Spec.OperatorSpec.ManagementState == operatorapi.Removed, nil
+}
 
-	existingRule, err := c.monitoringClient.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Get(ctx, prometheusRule.Name, metav1.GetOptions{})
-	if apierrors.IsNotFound(err) {
-		existingRule, err = c.monitoringClient.MonitoringV1().
-			PrometheusRules(prometheusRule.Namespace).Create(ctx, prometheusRule, metav1.CreateOptions{})
-		if err != nil {
-			return nil, false, fmt.Errorf("failed to create prometheus rule: %v", err)
-		}
-		return existingRule, true, nil
+func (c *monitoringController) syncPrometheusRule(ctx context.Context, prometheusRuleBytes []byte, cfg *DetectorConfig) (*promv1.PrometheusRule, bool, error) {
+	prometheusRule, err := c.parsePrometheusRule(prometheusRuleBytes)
+	if err != nil {
+		return nil, false, err
 	}
-
-	existingRuleCopy := existingRule.DeepCopy()
-	existingSpec := existingRuleCopy.Spec
-
-	modified := resourcemerge.BoolPtr(false)
-
-	resourcemerge.EnsureObjectMeta(modified, &existingRuleCopy.ObjectMeta, prometheusRule.ObjectMeta)
-	contentSame := equality.Semantic.DeepEqual(existingSpec, prometheusRule.Spec)
-	// no modifications are necessary everything is same
-	if contentSame && !*modified {
-		return existingRule, false, nil
+	if err := applyDetectorConfig(c.detector, prometheusRule, cfg); err != nil {
+		return nil, false, err
 	}
-
-	prometheusRule.ObjectMeta = *existingRuleCopy.ObjectMeta.DeepCopy()
-	prometheusRule.TypeMeta = existingRuleCopy.TypeMeta
-
-	klog.V(4).Infof("prometheus rule %s is modified outside of openshift - updating", prometheusRuleFile)
-	updatedRule, err := c.monitoringClient.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Update(ctx, prometheusRule, metav1.UpdateOptions{})
-	return updatedRule, true, err
+	return prometheusrule.ApplyPrometheusRule(ctx, c.monitoringClient, c.eventRecorder, prometheusRule)
 }
 
 func (c *monitoringController) deletePrometheusRule(ctx context.Context, prometheusRuleBytes []byte) error {
@@ -193,35 +162,56 @@ func (c *monitoringController) deletePrometheusRule(ctx context.Context, prometh
 	if err != nil {
 		return err
 	}
+	_, err = prometheusrule.DeletePrometheusRule(ctx, c.monitoringClient, c.eventRecorder, prometheusRule)
+	return err
+}
 
-	_, err = c.monitoringClient.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Get(ctx, prometheusRule.Name, metav1.GetOptions{})
-	if apierrors.IsNotFound(err) {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-
-	c.monitoringClient.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Delete(ctx, prometheusRule.Name, metav1.DeleteOptions{})
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			return nil
+// applyDetectorConfig updates alerts of the detector to match its configuration:
+//   - Alerts about checks in alertSuppressedChecks are not fired.
+//   - Severity of alerts is overridden.
+//   - No alerts are fired during the maintenance window.
+func applyDetectorConfig(detector *Detector, prometheusRule *promv1.PrometheusRule, cfg *DetectorConfig) error {
+	unknownAlerts := sets.KeySet(cfg.AlertSeverities)
+	suppressedChecks := cfg.suppressedChecks()
+	for i := range prometheusRule.Spec.Groups {
+		rules := prometheusRule.Spec.Groups[i].Rules
+		for j := range rules {
+			rule := &rules[j]
+			if rule.Alert == "" {
+				continue
+			}
+
+			expr := rule.Expr.String()
+			if len(suppressedChecks) > 0 {
+				matcher := fmt.Sprintf(`{check!~"%s"}[`, strings.Join(suppressedChecks, "|"))
+				for _, metric := range detector.checkMetrics() {
+					expr = strings.ReplaceAll(expr, metric+"[", metric+matcher)
+				}
+			}
+			if w := cfg.MaintenanceWindow; w != nil {
+				expr = fmt.Sprintf("(%s) unless on() (vector(time()) >= %d < %d)", strings.TrimSpace(expr), w.Start.Unix(), w.End.Unix())
+			}
+			rule.Expr = intstr.FromString(expr)
+
+			if severity, ok := cfg.AlertSeverities[rule.Alert]; ok {
+				if rule.Labels == nil {
+					rule.Labels = map[string]string{}
+				}
+				rule.Labels["severity"] = severity
+				unknownAlerts.Delete(rule.Alert)
+			}
 		}
-		return err
 	}
-	klog.V(2).Infof("prometheus rule %s deleted", prometheusRuleFile)
+	if unknownAlerts.Len() > 0 {
+		return fmt.Errorf("invalid config in ConfigMap %s: unknown alerts in alertSeverities: %s", detector.ConfigMapName, strings.Join(sets.List(unknownAlerts), ", "))
+	}
 	return nil
 }
 
 func (c *monitoringController) parsePrometheusRule(prometheusRuleBytes []byte) (*promv1.PrometheusRule, error) {
-	requiredObj, _, err := genericCodec.Decode(prometheusRuleBytes, nil, nil)
+	prometheusRule, err := prometheusrule.ReadPrometheusRule(prometheusRuleBytes)
 	if err != nil {
-		return nil, fmt.Errorf("cannot decode %q: %v", prometheusRuleFile, err)
-	}
-
-	prometheusRule, ok := requiredObj.(*promv1.PrometheusRule)
-	if !ok {
-		return nil, fmt.Errorf("invalid prometheusrule: %+v", requiredObj)
+		return nil, fmt.Errorf("invalid asset %s: %v", c.detector.PrometheusRuleAsset, err)
 	}
 	return prometheusRule, nil
 }
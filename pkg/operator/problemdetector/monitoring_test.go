@@ -0,0 +1,198 @@
+package problemdetector
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/library-go/pkg/operator/events"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestSyncPrometheusRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		inititialRules []*promv1.PrometheusRule
+		// we merely use this field as a marker in test to check if rule was applied properly
+		expectedAlertCountInRule int
+		modified                 bool
+	}{
+		{
+			name:                     "for new rule creation",
+			inititialRules:           []*promv1.PrometheusRule{},
+			expectedAlertCountInRule: 2,
+			modified:                 true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initialObjects := &csoclients.FakeTestObjects{}
+			for _, r := range test.inititialRules {
+				initialObjects.MonitoringObjects = append(initialObjects.MonitoringObjects, runtime.Object(r))
+			}
+
+			client := csoclients.NewFakeClients(initialObjects)
+			eventRecorder := events.NewInMemoryRecorder("vsphere-client", clocktesting.NewFakePassiveClock(time.Now()))
+			c := &monitoringController{
+				detector:         testDetector,
+				operatorClient:   client.OperatorClient,
+				kubeClient:       client.KubeClient,
+				dynamicClient:    client.DynamicClient,
+				eventRecorder:    eventRecorder,
+				monitoringClient: client.MonitoringClient,
+			}
+			ctx := context.TODO()
+			rule, modified, err := c.syncPrometheusRule(ctx, getPrometheusRuleRaw(), &defaultConfig)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if modified != test.modified {
+				t.Errorf("expected rule modification to be %v got %v", test.modified, modified)
+			}
+			actualRules := rule.Spec.Groups[0].Rules
+			if len(actualRules) != test.expectedAlertCountInRule {
+				t.Errorf("expected alert count in rule to be %d got %d", test.expectedAlertCountInRule, len(actualRules))
+			}
+		})
+
+	}
+}
+
+func TestApplyDetectorConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		cfg                *DetectorConfig
+		expectError        bool
+		expectedExpr       string
+		expectedSeverities map[string]string
+	}{
+		{
+			name:         "default config",
+			cfg:          &defaultConfig,
+			expectedExpr: "min_over_time(vsphere_node_check_errors[5m]) == 1",
+			expectedSeverities: map[string]string{
+				"VSphereOpenshiftNodeHealthFail":    "warning",
+				"VSphereOpenshiftClusterHealthFail": "critical",
+			},
+		},
+		{
+			name:         "suppressed checks",
+			cfg:          &DetectorConfig{AlertSuppressedChecks: []string{"CheckNodeDiskUUID", "CheckNodeDiskPerf"}},
+			expectedExpr: `min_over_time(vsphere_node_check_errors{check!~"CheckNodeDiskPerf|CheckNodeDiskUUID"}[5m]) == 1`,
+		},
+		{
+			name: "severity override",
+			cfg:  &DetectorConfig{AlertSeverities: map[string]string{"VSphereOpenshiftClusterHealthFail": "info"}},
+			expectedSeverities: map[string]string{
+				"VSphereOpenshiftNodeHealthFail":    "warning",
+				"VSphereOpenshiftClusterHealthFail": "info",
+			},
+		},
+		{
+			name:        "severity override of unknown alert",
+			cfg:         &DetectorConfig{AlertSeverities: map[string]string{"NoSuchAlert": "info"}},
+			expectError: true,
+		},
+		{
+			name: "maintenance window",
+			cfg: &DetectorConfig{MaintenanceWindow: &MaintenanceWindow{
+				Start: time.Unix(1000, 0),
+				End:   time.Unix(2000, 0),
+			}},
+			expectedExpr: "(min_over_time(vsphere_node_check_errors[5m]) == 1) unless on() (vector(time()) >= 1000 < 2000)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := (&monitoringController{detector: testDetector}).parsePrometheusRule(getPrometheusRuleRaw())
+			if err != nil {
+				t.Fatalf("failed to parse rule: %v", err)
+			}
+
+			err = applyDetectorConfig(testDetector, rule, test.cfg)
+			if test.expectError {
+				if err == nil {
+					t.Error("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rules := rule.Spec.Groups[0].Rules
+			if test.expectedExpr != "" && rules[0].Expr.String() != test.expectedExpr {
+				t.Errorf("unexpected expr:\nexpected: %s\ngot:      %s", test.expectedExpr, rules[0].Expr.String())
+			}
+			for _, r := range rules {
+				if expected, ok := test.expectedSeverities[r.Alert]; ok && r.Labels["severity"] != expected {
+					t.Errorf("expected severity %s of alert %s, got %s", expected, r.Alert, r.Labels["severity"])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDetectorConfigToAsset(t *testing.T) {
+	prometheusRuleBytes, err := assets.ReadFile(testDetector.PrometheusRuleAsset)
+	if err != nil {
+		t.Fatalf("failed to read asset: %v", err)
+	}
+	rule, err := (&monitoringController{detector: testDetector}).parsePrometheusRule(prometheusRuleBytes)
+	if err != nil {
+		t.Fatalf("failed to parse rule: %v", err)
+	}
+	cfg := &DetectorConfig{AlertSuppressedChecks: []string{"CheckNodeDiskPerf"}}
+	if err := applyDetectorConfig(testDetector, rule, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filtered := 0
+	for _, r := range rule.Spec.Groups[0].Rules {
+		if strings.Contains(r.Expr.String(), `{check!~"CheckNodeDiskPerf"}[5m]`) {
+			filtered++
+		}
+	}
+	// VSphereOpenshiftNodeHealthFail and VSphereOpenshiftClusterHealthFail
+	if filtered != 2 {
+		t.Errorf("expected 2 alerts with disabled checks filtered out, got %d", filtered)
+	}
+}
+
+func getPrometheusRuleRaw() []byte {
+	return []byte(`
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: vsphere-problem-detector
+  namespace: openshift-cluster-storage-operator
+  labels:
+    role: alert-rules
+spec:
+  groups:
+    - name: vsphere-problem-detector.rules
+      rules:
+      - alert: VSphereOpenshiftNodeHealthFail
+        expr:  min_over_time(vsphere_node_check_errors[5m]) == 1
+        for: 10m
+        labels:
+          severity: warning
+        annotations:
+          message: "Vsphere node health checks are failing on {{ $labels.node }} with {{ $labels.check }}"
+      - alert: VSphereOpenshiftClusterHealthFail
+        expr: vsphere_cluster_check_errors == 1
+        for: 10m
+        labels:
+          severity: critical
+        annotations:
+          message: "VSpehre cluster health checks are failing with {{ $labels.check }}"
+         `)
+}
@@ -0,0 +1,62 @@
+package problemdetector
+
+import (
+	"context"
+	"fmt"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// withRecheckHook copies the recheck annotation of the Storage CR to the detector pod template,
+// so a new value rolls out new detector pods, which run all checks on startup. The annotation
+// value is usually a timestamp, any change of the value restarts the detector.
+func withRecheckHook(detector *Detector, operatorClient v1helpers.OperatorClientWithFinalizers) deploymentcontroller.DeploymentHookFunc {
+	return func(opSpec *operatorapi.OperatorSpec, deployment *appsv1.Deployment) error {
+		trigger, err := recheckTrigger(detector, operatorClient)
+		if err != nil {
+			return err
+		}
+		if trigger == "" {
+			return nil
+		}
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		deployment.Spec.Template.Annotations[detector.RecheckAnnotation] = trigger
+		return nil
+	}
+}
+
+func recheckTrigger(detector *Detector, operatorClient v1helpers.OperatorClientWithFinalizers) (string, error) {
+	if detector.RecheckAnnotation == "" {
+		return "", nil
+	}
+	meta, err := operatorClient.GetObjectMeta()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Storage CR metadata: %w", err)
+	}
+	return meta.Annotations[detector.RecheckAnnotation], nil
+}
+
+// syncRecheckCondition reports the last recheck trigger in <ConditionPrefix>Recheck
+// condition. Nothing is reported until a recheck is requested.
+func (c *Starter) syncRecheckCondition(ctx context.Context) error {
+	trigger, err := recheckTrigger(c.detector, c.operatorClient)
+	if err != nil {
+		return err
+	}
+	if trigger == "" {
+		return nil
+	}
+	cond := operatorapi.OperatorCondition{
+		Type:    c.detector.recheckConditionType(),
+		Status:  operatorapi.ConditionTrue,
+		Reason:  "RecheckRequested",
+		Message: fmt.Sprintf("Last re-check of %s was requested by annotation %s=%s", c.detector.DisplayName, c.detector.RecheckAnnotation, trigger),
+	}
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond))
+	return err
+}
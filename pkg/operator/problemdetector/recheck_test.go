@@ -0,0 +1,78 @@
+package problemdetector
+
+import (
+	"context"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func withRecheckAnnotation(value string) csoclients.CrModifier {
+	return func(cr *opv1.Storage) *opv1.Storage {
+		cr.Annotations = map[string]string{testDetector.RecheckAnnotation: value}
+		return cr
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	tests := []struct {
+		name               string
+		cr                 *opv1.Storage
+		expectedAnnotation string
+		expectCondition    bool
+	}{
+		{
+			name: "no recheck",
+			cr:   csoclients.GetCR(),
+		},
+		{
+			name:               "recheck requested",
+			cr:                 csoclients.GetCR(withRecheckAnnotation("2026-10-19T10:00:00Z")),
+			expectedAnnotation: "2026-10-19T10:00:00Z",
+			expectCondition:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				OperatorObjects: []runtime.Object{tt.cr},
+			})
+
+			deployment := &appsv1.Deployment{}
+			if err := withRecheckHook(testDetector, clients.OperatorClient)(&tt.cr.Spec.OperatorSpec, deployment); err != nil {
+				t.Fatalf("unexpected hook error: %v", err)
+			}
+			if got := deployment.Spec.Template.Annotations[testDetector.RecheckAnnotation]; got != tt.expectedAnnotation {
+				t.Errorf("expected pod template annotation %q, got %q", tt.expectedAnnotation, got)
+			}
+
+			c := &Starter{detector: testDetector, operatorClient: clients.OperatorClient}
+			if err := c.syncRecheckCondition(context.TODO()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, status, _, err := clients.OperatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("failed to get Storage: %v", err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, testDetector.recheckConditionType())
+			if !tt.expectCondition {
+				if cond != nil {
+					t.Errorf("expected no %s condition, got %+v", testDetector.recheckConditionType(), cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatalf("%s condition not found", testDetector.recheckConditionType())
+			}
+			expectedMessage := "Last re-check of vSphere was requested by annotation storage.openshift.io/vsphere-recheck=" + tt.expectedAnnotation
+			if cond.Message != expectedMessage {
+				t.Errorf("expected message %q, got %q", expectedMessage, cond.Message)
+			}
+		})
+	}
+}
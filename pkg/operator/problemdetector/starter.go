@@ -0,0 +1,363 @@
+package problemdetector
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorapi "github.com/openshift/api/operator/v1"
+	openshiftv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/configobservation/util"
+	csotls "github.com/openshift/cluster-storage-operator/pkg/operator/tls"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/controller/manager"
+	"github.com/openshift/library-go/pkg/operator/csi/csidrivercontrollerservicecontroller"
+
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/loglevel"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+	"github.com/openshift/library-go/pkg/operator/staticresourcecontroller"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/klog/v2"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	infraConfigName              = "cluster"
+	cloudConfigNamespace         = "openshift-config"
+	trustedCABundleConfigMapName = "trusted-ca-bundle"
+)
+
+// Starter deploys a platform problem detector when the cluster runs on its platform.
+type Starter struct {
+	detector        *Detector
+	controller      manager.ControllerManager
+	operatorClient  v1helpers.OperatorClientWithFinalizers
+	infraLister     openshiftv1.InfrastructureLister
+	apiServerLister openshiftv1.APIServerLister
+	deployLister    appslisters.DeploymentNamespaceLister
+	kubeClient      kubernetes.Interface
+	versionGetter   status.VersionGetter
+	targetVersion   string
+	eventRecorder   events.Recorder
+	running         bool
+}
+
+// NewStarter returns a controller that starts all controllers of the detector
+// once the cluster is found to run on the detector platform.
+func NewStarter(
+	detector *Detector,
+	clients *csoclients.Clients,
+	resyncInterval time.Duration,
+	versionGetter status.VersionGetter,
+	targetVersion string,
+	eventRecorder events.Recorder) factory.Controller {
+	c := &Starter{
+		detector:        detector,
+		operatorClient:  clients.OperatorClient,
+		infraLister:     clients.ConfigInformers.Config().V1().Infrastructures().Lister(),
+		apiServerLister: clients.ConfigInformers.Config().V1().APIServers().Lister(),
+		deployLister:    clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Apps().V1().Deployments().Lister().Deployments(csoclients.OperatorNamespace),
+		kubeClient:      clients.KubeClient,
+		versionGetter:   versionGetter,
+		targetVersion:   targetVersion,
+		eventRecorder:   eventRecorder.WithComponentSuffix(detector.starterName()),
+	}
+	c.controller = c.createManager(clients, resyncInterval)
+	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(clients.OperatorClient).WithInformers(
+		clients.OperatorClient.Informer(),
+		clients.ConfigInformers.Config().V1().Infrastructures().Informer(),
+		clients.ConfigInformers.Config().V1().APIServers().Informer(),
+		clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Apps().V1().Deployments().Informer(),
+	).ToController(detector.starterName(), eventRecorder)
+}
+
+func (c *Starter) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("%s.Sync started", c.detector.starterName())
+	defer klog.V(4).Infof("%s.Sync finished", c.detector.starterName())
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	infrastructure, err := c.infraLister.Get(infraConfigName)
+	if err != nil {
+		return err
+	}
+
+	// Start controller managers for this platform
+	var platform configv1.PlatformType
+	if infrastructure.Status.PlatformStatus != nil {
+		platform = infrastructure.Status.PlatformStatus.Type
+	}
+
+	// if not on the detector platform return without any error
+	if platform != c.detector.Platform {
+		return nil
+	}
+
+	operatorConfigHash, err := c.reconcileOperatorConfigMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !c.running {
+		go c.controller.Start(ctx)
+		c.running = true
+	}
+	if err := c.syncRecheckCondition(ctx); err != nil {
+		return err
+	}
+	return c.syncTLSProfileCondition(ctx, operatorConfigHash)
+}
+
+// syncTLSProfileCondition reports whether the detector Deployment already runs with the current
+// operator config. The Deployment is created by the detector Deployment controller,
+// so there is nothing to report until it exists.
+func (c *Starter) syncTLSProfileCondition(ctx context.Context, operatorConfigHash string) error {
+	deployment, err := c.deployLister.Get(c.detector.DeploymentName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient,
+		v1helpers.UpdateConditionFn(csotls.TLSProfileProgressingCondition(c.detector.ConditionPrefix, deployment, operatorConfigHash)))
+	return err
+}
+
+func (c *Starter) createManager(
+	clients *csoclients.Clients,
+	resyncInterval time.Duration) manager.ControllerManager {
+	mgr := manager.NewControllerManager()
+
+	mgr = mgr.WithController(staticresourcecontroller.NewStaticResourceController(
+		c.detector.staticControllerName(),
+		assets.ReadFile,
+		c.detector.StaticAssets,
+		resourceapply.NewKubeClientHolder(clients.KubeClient),
+		c.operatorClient,
+		c.eventRecorder).AddKubeInformers(clients.KubeInformers), 1)
+
+	deploymentAssets, err := assets.ReadFile(c.detector.DeploymentAsset)
+	if err != nil {
+		panic(err)
+	}
+
+	deploymentController, err := deploymentcontroller.NewDeploymentControllerBuilder(
+		c.detector.deploymentControllerName(),
+		deploymentAssets,
+		c.eventRecorder,
+		clients.OperatorClient,
+		clients.KubeClient,
+		clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Apps().V1().Deployments(),
+	).WithExtraInformers(
+		clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().Secrets().Informer(),
+		clients.ConfigInformers.Config().V1().Infrastructures().Informer(),
+		clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+	).WithManifestHooks(
+		c.withReplacerHook(),
+	).WithDeploymentHooks(
+		csidrivercontrollerservicecontroller.WithControlPlaneTopologyHook(clients.ConfigInformers),
+		withProxyHook(),
+		// Restart when credentials change to get a quick retest
+		csidrivercontrollerservicecontroller.WithSecretHashAnnotationHook(
+			csoclients.OperatorNamespace,
+			c.detector.CredentialsSecretName,
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().Secrets(),
+		),
+		// Restart when serving-cert changes
+		csidrivercontrollerservicecontroller.WithSecretHashAnnotationHook(
+			csoclients.OperatorNamespace,
+			c.detector.MetricsCertSecretName,
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().Secrets(),
+		),
+		// Restart when cloud config changes to get a quick retest
+		c.WithConfigMapHashAnnotationHook(
+			cloudConfigNamespace,
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps(),
+		),
+		// Restart when the trusted CA bundle changes, e.g. a new proxy CA
+		csidrivercontrollerservicecontroller.WithConfigMapHashAnnotationHook(
+			csoclients.OperatorNamespace,
+			trustedCABundleConfigMapName,
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps(),
+		),
+		// Restart when the admin requests a re-check
+		withRecheckHook(c.detector, c.operatorClient),
+		// Restart when the TLS profile in the operator config changes
+		withOperatorConfigHashHook(
+			c.detector.OperatorConfigName,
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps(),
+		),
+	).WithConditions(
+		// No Available Condition
+		operatorapi.OperatorStatusTypeProgressing,
+		operatorapi.OperatorStatusTypeDegraded,
+	).ToController()
+
+	if err != nil {
+		panic(err)
+	}
+
+	mgr = mgr.WithController(deploymentController, 1)
+
+	mgr = mgr.WithController(newMonitoringController(
+		c.detector,
+		clients,
+		c.eventRecorder,
+		resyncInterval), 1)
+
+	checksController, err := newChecksController(c.detector, clients, c.eventRecorder, resyncInterval)
+	if err != nil {
+		panic(err)
+	}
+	mgr = mgr.WithController(checksController, 1)
+
+	return mgr
+}
+
+func (c *Starter) withReplacerHook() deploymentcontroller.ManifestHookFunc {
+	return func(spec *operatorapi.OperatorSpec, deployment []byte) ([]byte, error) {
+		logLevel := loglevel.LogLevelToVerbosity(spec.LogLevel)
+		pairs := []string{
+			"${OPERATOR_IMAGE}", os.Getenv(c.detector.ImageEnvVar),
+			"${LOG_LEVEL}", strconv.Itoa(logLevel),
+		}
+
+		replacer := strings.NewReplacer(pairs...)
+		newDeployment := replacer.Replace(string(deployment))
+		return []byte(newDeployment), nil
+	}
+}
+
+func (c *Starter) WithConfigMapHashAnnotationHook(namespace string, cmInformer coreinformers.ConfigMapInformer) deploymentcontroller.DeploymentHookFunc {
+	return func(opSpec *operatorapi.OperatorSpec, deployment *appsv1.Deployment) error {
+		// Find cloud-config ConfigMap name from Infrastructure
+		infra, err := c.infraLister.Get(infraConfigName)
+		if err != nil {
+			return err
+		}
+		cloudConfigName := infra.Spec.CloudConfig.Name
+
+		// Compute ConfigMap hash
+		inputHashes, err := resourcehash.MultipleObjectHashStringMapForObjectReferenceFromLister(
+			cmInformer.Lister(),
+			nil,
+			resourcehash.NewObjectRef().ForConfigMap().InNamespace(namespace).Named(cloudConfigName),
+		)
+		if err != nil {
+			return fmt.Errorf("invalid dependency reference: %w", err)
+		}
+
+		// Add the hash to Deployment annotations
+		return addObjectHash(deployment, inputHashes)
+	}
+}
+
+// withOperatorConfigHashHook annotates the Deployment with hash of the operator config
+// ConfigMap, which is created by the Starter before the Deployment controller starts.
+func withOperatorConfigHashHook(operatorConfigName string, cmInformer coreinformers.ConfigMapInformer) deploymentcontroller.DeploymentHookFunc {
+	return func(opSpec *operatorapi.OperatorSpec, deployment *appsv1.Deployment) error {
+		cm, err := cmInformer.Lister().ConfigMaps(csoclients.OperatorNamespace).Get(operatorConfigName)
+		if err != nil {
+			return fmt.Errorf("failed to get operator config ConfigMap: %w", err)
+		}
+		hash, err := csotls.OperatorConfigHash(cm)
+		if err != nil {
+			return err
+		}
+		csotls.SetOperatorConfigHash(deployment, hash)
+		return nil
+	}
+}
+
+// reconcileOperatorConfigMap applies the operator config ConfigMap with TLS settings from
+// APIServer/cluster and returns hash of the applied ConfigMap.
+func (c *Starter) reconcileOperatorConfigMap(ctx context.Context) (string, error) {
+	assetBytes, err := assets.ReadFile(c.detector.OperatorConfigAsset)
+	if err != nil {
+		return "", fmt.Errorf("failed to read operator config asset: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := sigsyaml.Unmarshal(assetBytes, cm); err != nil {
+		return "", fmt.Errorf("failed to decode operator config ConfigMap: %w", err)
+	}
+
+	apiServer, err := c.apiServerLister.Get("cluster")
+	if err != nil {
+		return "", fmt.Errorf("failed to get APIServer cluster: %w", err)
+	}
+	yaml, err := csotls.OperatorConfigYAML(csotls.TLSSettingsFromProfile(apiServer.Spec.TLSSecurityProfile))
+	if err != nil {
+		return "", err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data["config.yaml"] = yaml
+
+	applied, _, err := resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, cm)
+	if err != nil {
+		return "", err
+	}
+	return csotls.OperatorConfigHash(applied)
+}
+
+func addObjectHash(deployment *appsv1.Deployment, inputHashes map[string]string) error {
+	if deployment == nil {
+		return fmt.Errorf("invalid deployment: %v", deployment)
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	for k, v := range inputHashes {
+		annotationKey := fmt.Sprintf("operator.openshift.io/dep-%s", k)
+		if len(annotationKey) > 63 {
+			hash := sha256.Sum256([]byte(k))
+			annotationKey = fmt.Sprintf("operator.openshift.io/dep-%x", hash)
+			annotationKey = annotationKey[:63]
+		}
+		deployment.Annotations[annotationKey] = v
+		deployment.Spec.Template.Annotations[annotationKey] = v
+	}
+	return nil
+}
+
+func withProxyHook() deploymentcontroller.DeploymentHookFunc {
+	return func(opSpec *operatorapi.OperatorSpec, deployment *appsv1.Deployment) error {
+		// Cannot use csidrivercontrollerservicecontroller.WithObservedProxyDeploymentHook here.
+		// It expects proxy config at spec.observedConfig.targetcsiconfig.proxy,
+		// while CSO uses spec.observedConfig.targetconfig.proxy
+		err := util.InjectObservedProxyInDeploymentContainers(deployment, opSpec)
+		return err
+	}
+}
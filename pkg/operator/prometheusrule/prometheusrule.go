@@ -0,0 +1,111 @@
+package prometheusrule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	promclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	promscheme "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/scheme"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/klog/v2"
+)
+
+var (
+	genericScheme = runtime.NewScheme()
+	genericCodecs = serializer.NewCodecFactory(genericScheme)
+	genericCodec  = genericCodecs.UniversalDeserializer()
+)
+
+func init() {
+	if err := promscheme.AddToScheme(genericScheme); err != nil {
+		panic(err)
+	}
+}
+
+// ReadPrometheusRule decodes a PrometheusRule from YAML or JSON.
+func ReadPrometheusRule(prometheusRuleBytes []byte) (*promv1.PrometheusRule, error) {
+	requiredObj, _, err := genericCodec.Decode(prometheusRuleBytes, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode PrometheusRule: %v", err)
+	}
+
+	prometheusRule, ok := requiredObj.(*promv1.PrometheusRule)
+	if !ok {
+		return nil, fmt.Errorf("invalid prometheusrule: %+v", requiredObj)
+	}
+	return prometheusRule, nil
+}
+
+// ApplyPrometheusRule creates the required PrometheusRule or updates the existing one when it
+// differs. It returns the resulting rule and whether it was modified.
+func ApplyPrometheusRule(ctx context.Context, client promclient.Interface, recorder events.Recorder, required *promv1.PrometheusRule) (*promv1.PrometheusRule, bool, error) {
+	existingRule, err := client.MonitoringV1().PrometheusRules(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		existingRule, err = client.MonitoringV1().PrometheusRules(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
+		if err != nil {
+			recorder.Warningf("PrometheusRuleCreateFailed", "Failed to create PrometheusRule %s/%s: %v", required.Namespace, required.Name, err)
+			return nil, false, fmt.Errorf("failed to create prometheus rule: %v", err)
+		}
+		recorder.Eventf("PrometheusRuleCreated", "Created PrometheusRule %s/%s because it was missing", required.Namespace, required.Name)
+		return existingRule, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingRuleCopy := existingRule.DeepCopy()
+	existingSpec := existingRuleCopy.Spec
+
+	modified := resourcemerge.BoolPtr(false)
+
+	resourcemerge.EnsureObjectMeta(modified, &existingRuleCopy.ObjectMeta, required.ObjectMeta)
+	contentSame := equality.Semantic.DeepEqual(existingSpec, required.Spec)
+	// no modifications are necessary everything is same
+	if contentSame && !*modified {
+		return existingRule, false, nil
+	}
+
+	required = required.DeepCopy()
+	required.ObjectMeta = *existingRuleCopy.ObjectMeta.DeepCopy()
+	required.TypeMeta = existingRuleCopy.TypeMeta
+
+	klog.V(4).Infof("PrometheusRule %s/%s is modified outside of openshift - updating", required.Namespace, required.Name)
+	updatedRule, err := client.MonitoringV1().PrometheusRules(required.Namespace).Update(ctx, required, metav1.UpdateOptions{})
+	if err != nil {
+		recorder.Warningf("PrometheusRuleUpdateFailed", "Failed to update PrometheusRule %s/%s: %v", required.Namespace, required.Name, err)
+		return nil, false, err
+	}
+	recorder.Eventf("PrometheusRuleUpdated", "Updated PrometheusRule %s/%s because it changed", required.Namespace, required.Name)
+	return updatedRule, true, nil
+}
+
+// DeletePrometheusRule deletes the PrometheusRule. It returns whether the rule was deleted,
+// a missing rule is not an error.
+func DeletePrometheusRule(ctx context.Context, client promclient.Interface, recorder events.Recorder, prometheusRule *promv1.PrometheusRule) (bool, error) {
+	_, err := client.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Get(ctx, prometheusRule.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = client.MonitoringV1().PrometheusRules(prometheusRule.Namespace).Delete(ctx, prometheusRule.Name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		recorder.Warningf("PrometheusRuleDeleteFailed", "Failed to delete PrometheusRule %s/%s: %v", prometheusRule.Namespace, prometheusRule.Name, err)
+		return false, err
+	}
+	recorder.Eventf("PrometheusRuleDeleted", "Deleted PrometheusRule %s/%s", prometheusRule.Namespace, prometheusRule.Name)
+	klog.V(2).Infof("PrometheusRule %s/%s deleted", prometheusRule.Namespace, prometheusRule.Name)
+	return true, nil
+}
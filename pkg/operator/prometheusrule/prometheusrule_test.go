@@ -0,0 +1,159 @@
+package prometheusrule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	promfake "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const testRule = `
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: test-rules
+  namespace: openshift-cluster-csi-drivers
+spec:
+  groups:
+    - name: test.rules
+      rules:
+      - alert: TestAlert
+        expr: test_metric == 1
+`
+
+func readTestRule(t *testing.T) *promv1.PrometheusRule {
+	rule, err := ReadPrometheusRule([]byte(testRule))
+	if err != nil {
+		t.Fatalf("failed to read rule: %v", err)
+	}
+	return rule
+}
+
+func TestApplyPrometheusRule(t *testing.T) {
+	changedRule := func(t *testing.T) *promv1.PrometheusRule {
+		rule := readTestRule(t)
+		rule.Spec.Groups[0].Rules[0].Expr = intstr.FromString("test_metric == 2")
+		return rule
+	}
+
+	tests := []struct {
+		name             string
+		existing         []runtime.Object
+		expectedModified bool
+		expectedEvent    string
+	}{
+		{
+			name:             "missing rule",
+			expectedModified: true,
+			expectedEvent:    "PrometheusRuleCreated",
+		},
+		{
+			name:     "unchanged rule",
+			existing: []runtime.Object{readTestRule(t)},
+		},
+		{
+			name:             "changed rule",
+			existing:         []runtime.Object{changedRule(t)},
+			expectedModified: true,
+			expectedEvent:    "PrometheusRuleUpdated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := promfake.NewSimpleClientset(tt.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			rule, modified, err := ApplyPrometheusRule(context.TODO(), client, recorder, readTestRule(t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if modified != tt.expectedModified {
+				t.Errorf("expected modified %v, got %v", tt.expectedModified, modified)
+			}
+			if expr := rule.Spec.Groups[0].Rules[0].Expr.String(); expr != "test_metric == 1" {
+				t.Errorf("unexpected expr %q", expr)
+			}
+			if err := checkEvent(recorder, tt.expectedEvent); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDeletePrometheusRule(t *testing.T) {
+	tests := []struct {
+		name            string
+		existing        []runtime.Object
+		deleteErr       error
+		expectedDeleted bool
+		expectErr       bool
+		expectedEvent   string
+	}{
+		{
+			name: "missing rule",
+		},
+		{
+			name:            "existing rule",
+			existing:        []runtime.Object{readTestRule(t)},
+			expectedDeleted: true,
+			expectedEvent:   "PrometheusRuleDeleted",
+		},
+		{
+			name:          "delete error",
+			existing:      []runtime.Object{readTestRule(t)},
+			deleteErr:     apierrors.NewForbidden(promv1.Resource("prometheusrules"), "test-rules", fmt.Errorf("test")),
+			expectErr:     true,
+			expectedEvent: "PrometheusRuleDeleteFailed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := promfake.NewSimpleClientset(tt.existing...)
+			if tt.deleteErr != nil {
+				client.PrependReactor("delete", "prometheusrules", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.deleteErr
+				})
+			}
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			deleted, err := DeletePrometheusRule(context.TODO(), client, recorder, readTestRule(t))
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if deleted != tt.expectedDeleted {
+				t.Errorf("expected deleted %v, got %v", tt.expectedDeleted, deleted)
+			}
+			_, err = client.MonitoringV1().PrometheusRules("openshift-cluster-csi-drivers").Get(context.TODO(), "test-rules", metav1.GetOptions{})
+			if exists := err == nil; exists != (len(tt.existing) > 0 && !tt.expectedDeleted) {
+				t.Errorf("unexpected rule existence %v after delete", exists)
+			}
+			if err := checkEvent(recorder, tt.expectedEvent); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func checkEvent(recorder events.InMemoryRecorder, expectedReason string) error {
+	recorded := recorder.Events()
+	if expectedReason == "" {
+		if len(recorded) > 0 {
+			return fmt.Errorf("expected no events, got %s", recorded[0].Reason)
+		}
+		return nil
+	}
+	if len(recorded) != 1 || recorded[0].Reason != expectedReason {
+		return fmt.Errorf("expected event %s, got %d events", expectedReason, len(recorded))
+	}
+	return nil
+}
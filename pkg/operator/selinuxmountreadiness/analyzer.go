@@ -0,0 +1,325 @@
+package selinuxmountreadiness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/pager"
+)
+
+const (
+	// selinuxChangePolicyLabel on a namespace sets the default SELinuxChangePolicy of its pods.
+	selinuxChangePolicyLabel = performantpolicy.SELinuxChangePolicyLabel
+	// suggestedLabel resolves the conflicts of all pods in a namespace.
+	suggestedLabel = selinuxChangePolicyLabel + "=" + string(corev1.SELinuxChangePolicyRecursive)
+)
+
+// conflictSummary lists pods that share a volume with a pod with a different SELinux
+// label or SELinuxChangePolicy, i.e. pods that could not run together with SELinuxMount GA.
+type conflictSummary struct {
+	SuggestedLabel string               `yaml:"suggestedLabel"`
+	Namespaces     []namespaceConflicts `yaml:"namespaces"`
+	// MoreNamespaces is the number of affected namespaces left out of a truncated summary.
+	MoreNamespaces int `yaml:"moreNamespaces,omitempty"`
+}
+
+type namespaceConflicts struct {
+	Namespace string   `yaml:"namespace"`
+	Pods      []string `yaml:"pods"`
+	// MorePods is the number of affected pods left out of a truncated summary.
+	MorePods int `yaml:"morePods,omitempty"`
+}
+
+// podVolumeSettings are SELinux settings of a pod that affect mount of one of its volumes.
+type podVolumeSettings struct {
+	label  string
+	policy corev1.PodSELinuxChangePolicy
+}
+
+// getPodVolumeSettings returns SELinux settings of the pod for the given volume. The pod
+// change policy defaults to the policy from its namespace label.
+// Like the SELinuxWarningController, the label is taken from the effective seLinuxOptions
+// of the first container that mounts the volume, where container options replace the pod ones.
+func getPodVolumeSettings(pod *corev1.Pod, volumeName string, namespace *corev1.Namespace) podVolumeSettings {
+	settings := podVolumeSettings{
+		policy: corev1.SELinuxChangePolicyMountOption,
+	}
+	if namespace != nil {
+		switch policy := corev1.PodSELinuxChangePolicy(namespace.Labels[selinuxChangePolicyLabel]); policy {
+		case corev1.SELinuxChangePolicyRecursive, corev1.SELinuxChangePolicyMountOption:
+			settings.policy = policy
+		}
+	}
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.SELinuxChangePolicy != nil {
+		settings.policy = *sc.SELinuxChangePolicy
+	}
+	if opts := effectiveSELinuxOptions(pod, volumeName); opts != nil {
+		settings.label = strings.Join([]string{opts.User, opts.Role, opts.Type, opts.Level}, ":")
+	}
+	return settings
+}
+
+// effectiveSELinuxOptions returns seLinuxOptions of the first container that mounts the volume.
+func effectiveSELinuxOptions(pod *corev1.Pod, volumeName string) *corev1.SELinuxOptions {
+	var podOpts *corev1.SELinuxOptions
+	if pod.Spec.SecurityContext != nil {
+		podOpts = pod.Spec.SecurityContext.SELinuxOptions
+	}
+	containerOpts := func(sc *corev1.SecurityContext, mounts []corev1.VolumeMount) (*corev1.SELinuxOptions, bool) {
+		for _, mount := range mounts {
+			if mount.Name != volumeName {
+				continue
+			}
+			if sc != nil && sc.SELinuxOptions != nil {
+				return sc.SELinuxOptions, true
+			}
+			return podOpts, true
+		}
+		return nil, false
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if opts, found := containerOpts(c.SecurityContext, c.VolumeMounts); found {
+			return opts
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if opts, found := containerOpts(c.SecurityContext, c.VolumeMounts); found {
+			return opts
+		}
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		if opts, found := containerOpts(c.SecurityContext, c.VolumeMounts); found {
+			return opts
+		}
+	}
+	// The volume is not mounted by any container
+	return podOpts
+}
+
+// conflictsWith mirrors the rules of the SELinuxWarningController: pods conflict when
+// they use different change policies or when their volumes would be mounted with
+// different SELinux contexts.
+func (s podVolumeSettings) conflictsWith(other podVolumeSettings) bool {
+	if s.policy != other.policy {
+		return true
+	}
+	return s.policy == corev1.SELinuxChangePolicyMountOption && s.label != other.label
+}
+
+// listPageSize limits the size of LIST responses, the analysis lists all pods in the cluster.
+const listPageSize = 500
+
+// conflictAnalyzer finds conflicting pods, as a replacement of the SELinuxWarningController
+// when it does not report its results. Pods and volumes are listed from the API server
+// in pages only when the analysis runs, they are not kept in informers.
+type conflictAnalyzer struct {
+	kubeClient      kubernetes.Interface
+	namespaceLister corelisters.NamespaceLister
+	csiDriverLister storagelisters.CSIDriverLister
+}
+
+// podRef is a pod that uses a shared volume, with its SELinux settings.
+type podRef struct {
+	namespace string
+	name      string
+	settings  podVolumeSettings
+}
+
+// analyze finds pods that share a PersistentVolume with a conflicting pod.
+func (a *conflictAnalyzer) analyze(ctx context.Context) (*conflictSummary, error) {
+	// PersistentVolumes that can be shared by pods and will be mounted with -o context
+	sharedPVs := sets.New[string]()
+	err := a.eachItem(ctx, func(opts metav1.ListOptions) (runtime.Object, error) {
+		return a.kubeClient.CoreV1().PersistentVolumes().List(ctx, opts)
+	}, metav1.ListOptions{}, func(obj runtime.Object) error {
+		pv := obj.(*corev1.PersistentVolume)
+		if isReadWriteOncePod(pv) {
+			// ReadWriteOncePod volumes are mounted with the SELinux context already
+			// and they cannot be shared.
+			return nil
+		}
+		supported, err := a.supportsSELinuxMount(pv)
+		if err != nil {
+			return err
+		}
+		if supported {
+			sharedPVs.Insert(pv.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PersistentVolumes: %w", err)
+	}
+
+	// PVC namespace/name -> PV name, only for PVCs bound to shared PVs
+	claims := map[string]string{}
+	err = a.eachItem(ctx, func(opts metav1.ListOptions) (runtime.Object, error) {
+		return a.kubeClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, opts)
+	}, metav1.ListOptions{}, func(obj runtime.Object) error {
+		pvc := obj.(*corev1.PersistentVolumeClaim)
+		// Unbound volumes are not mounted yet
+		if pvc.Spec.VolumeName != "" && sharedPVs.Has(pvc.Spec.VolumeName) {
+			claims[pvc.Namespace+"/"+pvc.Name] = pvc.Spec.VolumeName
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PersistentVolumeClaims: %w", err)
+	}
+
+	podsByVolume := map[string][]podRef{}
+	podOpts := metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+		).String(),
+	}
+	err = a.eachItem(ctx, func(opts metav1.ListOptions) (runtime.Object, error) {
+		return a.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+	}, podOpts, func(obj runtime.Object) error {
+		pod := obj.(*corev1.Pod)
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			return nil
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvName, found := claims[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]
+			if !found {
+				continue
+			}
+			podsByVolume[pvName] = append(podsByVolume[pvName], podRef{
+				namespace: pod.Namespace,
+				name:      pod.Name,
+				settings:  a.podVolumeSettings(pod, volume.Name),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pods: %w", err)
+	}
+
+	conflictingPods := map[string]map[string]bool{}
+	for _, volumePods := range podsByVolume {
+		for i := range volumePods {
+			for j := i + 1; j < len(volumePods); j++ {
+				if !volumePods[i].settings.conflictsWith(volumePods[j].settings) {
+					continue
+				}
+				for _, pod := range []podRef{volumePods[i], volumePods[j]} {
+					if conflictingPods[pod.namespace] == nil {
+						conflictingPods[pod.namespace] = map[string]bool{}
+					}
+					conflictingPods[pod.namespace][pod.name] = true
+				}
+			}
+		}
+	}
+
+	summary := &conflictSummary{SuggestedLabel: suggestedLabel}
+	for namespace, podNames := range conflictingPods {
+		nsConflicts := namespaceConflicts{Namespace: namespace}
+		for name := range podNames {
+			nsConflicts.Pods = append(nsConflicts.Pods, name)
+		}
+		sort.Strings(nsConflicts.Pods)
+		summary.Namespaces = append(summary.Namespaces, nsConflicts)
+	}
+	// The most affected namespaces first
+	sort.Slice(summary.Namespaces, func(i, j int) bool {
+		a, b := summary.Namespaces[i], summary.Namespaces[j]
+		if len(a.Pods) != len(b.Pods) {
+			return len(a.Pods) > len(b.Pods)
+		}
+		return a.Namespace < b.Namespace
+	})
+	return summary, nil
+}
+
+// eachItem calls fn for each object returned by the paged list.
+func (a *conflictAnalyzer) eachItem(ctx context.Context, list func(metav1.ListOptions) (runtime.Object, error), opts metav1.ListOptions, fn func(runtime.Object) error) error {
+	p := pager.New(pager.SimplePageFunc(list))
+	p.PageSize = listPageSize
+	return p.EachListItem(ctx, opts, fn)
+}
+
+func (a *conflictAnalyzer) podVolumeSettings(pod *corev1.Pod, volumeName string) podVolumeSettings {
+	namespace, err := a.namespaceLister.Get(pod.Namespace)
+	if err != nil {
+		// A pod in a namespace being created or deleted, use the defaults
+		namespace = nil
+	}
+	return getPodVolumeSettings(pod, volumeName, namespace)
+}
+
+// supportsSELinuxMount returns true when the volume is mounted with -o context in SELinuxMount GA,
+// i.e. it is a CSI volume and its CSIDriver has seLinuxMount: true. Other volumes, such as NFS
+// or in-tree volumes, are relabeled recursively and they do not conflict.
+func (a *conflictAnalyzer) supportsSELinuxMount(pv *corev1.PersistentVolume) (bool, error) {
+	if pv.Spec.CSI == nil {
+		return false, nil
+	}
+	csiDriver, err := a.csiDriverLister.Get(pv.Spec.CSI.Driver)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return csiDriver.Spec.SELinuxMount != nil && *csiDriver.Spec.SELinuxMount, nil
+}
+
+func isReadWriteOncePod(pv *corev1.PersistentVolume) bool {
+	return len(pv.Spec.AccessModes) == 1 && pv.Spec.AccessModes[0] == corev1.ReadWriteOncePod
+}
+
+// truncate returns a copy of the summary with up to maxNamespaces most affected namespaces
+// and up to maxPods pods in each of them. Left out namespaces and pods are counted in
+// MoreNamespaces and MorePods.
+func (s *conflictSummary) truncate(maxNamespaces, maxPods int) *conflictSummary {
+	truncated := &conflictSummary{SuggestedLabel: s.SuggestedLabel}
+	for i, ns := range s.Namespaces {
+		if i == maxNamespaces {
+			truncated.MoreNamespaces = len(s.Namespaces) - maxNamespaces
+			break
+		}
+		nsConflicts := namespaceConflicts{Namespace: ns.Namespace, Pods: ns.Pods}
+		if len(ns.Pods) > maxPods {
+			nsConflicts.Pods = ns.Pods[:maxPods]
+			nsConflicts.MorePods = len(ns.Pods) - maxPods
+		}
+		truncated.Namespaces = append(truncated.Namespaces, nsConflicts)
+	}
+	return truncated
+}
+
+// topNamespaces returns a human readable list of up to n most affected namespaces.
+func (s *conflictSummary) topNamespaces(n int) string {
+	var items []string
+	for i, ns := range s.Namespaces {
+		if i == n {
+			items = append(items, fmt.Sprintf("and %d more", len(s.Namespaces)-n))
+			break
+		}
+		pods := "pods"
+		if len(ns.Pods) == 1 {
+			pods = "pod"
+		}
+		items = append(items, fmt.Sprintf("%s (%d %s)", ns.Namespace, len(ns.Pods), pods))
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,340 @@
+package selinuxmountreadiness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// testCSIDriverName is the CSI driver of testPV volumes.
+const testCSIDriverName = "csi.example.com"
+
+func testCSIDriver(name string, seLinuxMount bool) *storagev1.CSIDriver {
+	return &storagev1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       storagev1.CSIDriverSpec{SELinuxMount: &seLinuxMount},
+	}
+}
+
+func testPV(name string, accessModes ...corev1.PersistentVolumeAccessMode) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			AccessModes: accessModes,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: testCSIDriverName, VolumeHandle: name},
+			},
+		},
+	}
+}
+
+func nfsPV(name string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				NFS: &corev1.NFSVolumeSource{Server: "nfs.example.com", Path: "/" + name},
+			},
+		},
+	}
+}
+
+func testNamespace(name, policy string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if policy != "" {
+		ns.Labels = map[string]string{selinuxChangePolicyLabel: policy}
+	}
+	return ns
+}
+
+func boundPVC(namespace, name, volumeName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+	}
+}
+
+// podWithPVC returns a pod that uses the given PVC with the given SELinux level and change policy.
+// Empty policy means the default.
+func podWithPVC(namespace, name, claimName, level string, policy corev1.PodSELinuxChangePolicy) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{},
+			Containers: []corev1.Container{
+				{
+					Name:         "app",
+					VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+					},
+				},
+			},
+		},
+	}
+	if level != "" {
+		pod.Spec.SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Level: level}
+	}
+	if policy != "" {
+		pod.Spec.SecurityContext.SELinuxChangePolicy = &policy
+	}
+	return pod
+}
+
+// withContainerLevel sets the SELinux level of the pod container.
+func withContainerLevel(pod *corev1.Pod, level string) *corev1.Pod {
+	pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+		SELinuxOptions: &corev1.SELinuxOptions{Level: level},
+	}
+	return pod
+}
+
+func TestAnalyzeConflicts(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []*corev1.Namespace
+		csiDrivers []*storagev1.CSIDriver
+		pvs        []*corev1.PersistentVolume
+		pvcs       []*corev1.PersistentVolumeClaim
+		pods       []*corev1.Pod
+		expected   []namespaceConflicts
+	}{
+		{
+			name: "same label",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c1,c2", ""),
+			},
+		},
+		{
+			name: "different labels",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+			expected: []namespaceConflicts{{Namespace: "ns1", Pods: []string{"pod1", "pod2"}}},
+		},
+		{
+			name: "different labels with Recursive policy",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", corev1.SELinuxChangePolicyRecursive),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", corev1.SELinuxChangePolicyRecursive),
+			},
+		},
+		{
+			name: "different policies",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", corev1.SELinuxChangePolicyRecursive),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c1,c2", ""),
+			},
+			expected: []namespaceConflicts{{Namespace: "ns1", Pods: []string{"pod1", "pod2"}}},
+		},
+		{
+			name:       "namespace label sets Recursive policy",
+			namespaces: []*corev1.Namespace{testNamespace("ns1", "Recursive")},
+			pvcs:       []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name:       "pod policy overrides namespace label",
+			namespaces: []*corev1.Namespace{testNamespace("ns1", "Recursive")},
+			pvcs:       []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", corev1.SELinuxChangePolicyMountOption),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+			expected: []namespaceConflicts{{Namespace: "ns1", Pods: []string{"pod1", "pod2"}}},
+		},
+		{
+			name: "ReadWriteOncePod volume",
+			pvs:  []*corev1.PersistentVolume{testPV("pv1", corev1.ReadWriteOncePod)},
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name: "NFS volume",
+			pvs:  []*corev1.PersistentVolume{nfsPV("pv1")},
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name:       "CSI driver without SELinuxMount",
+			csiDrivers: []*storagev1.CSIDriver{testCSIDriver(testCSIDriverName, false)},
+			pvcs:       []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name:       "missing CSIDriver",
+			csiDrivers: []*storagev1.CSIDriver{},
+			pvcs:       []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name: "different container labels",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				withContainerLevel(podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""), "s0:c3,c4"),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c1,c2", ""),
+			},
+			expected: []namespaceConflicts{{Namespace: "ns1", Pods: []string{"pod1", "pod2"}}},
+		},
+		{
+			name: "container label overrides pod label",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "pv1")},
+			pods: []*corev1.Pod{
+				withContainerLevel(podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""), "s0:c3,c4"),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name: "unbound PVC",
+			pvcs: []*corev1.PersistentVolumeClaim{boundPVC("ns1", "pvc", "")},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+			},
+		},
+		{
+			name: "namespaces sorted by number of pods",
+			pvcs: []*corev1.PersistentVolumeClaim{
+				boundPVC("ns1", "pvc", "pv1"),
+				boundPVC("ns2", "pvc", "pv2"),
+			},
+			pods: []*corev1.Pod{
+				podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+				podWithPVC("ns2", "pod1", "pvc", "s0:c1,c2", ""),
+				podWithPVC("ns2", "pod2", "pvc", "s0:c3,c4", ""),
+				podWithPVC("ns2", "pod3", "pvc", "s0:c3,c4", ""),
+			},
+			expected: []namespaceConflicts{
+				{Namespace: "ns2", Pods: []string{"pod1", "pod2", "pod3"}},
+				{Namespace: "ns1", Pods: []string{"pod1", "pod2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			for _, pod := range tt.pods {
+				objects = append(objects, pod)
+			}
+			for _, pvc := range tt.pvcs {
+				objects = append(objects, pvc)
+				if len(tt.pvs) == 0 {
+					objects = append(objects, testPV(pvc.Spec.VolumeName, corev1.ReadWriteMany))
+				}
+			}
+			for _, pv := range tt.pvs {
+				objects = append(objects, pv)
+			}
+			namespaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, ns := range tt.namespaces {
+				if err := namespaceIndexer.Add(ns); err != nil {
+					t.Fatalf("failed to add namespace: %v", err)
+				}
+			}
+			csiDrivers := tt.csiDrivers
+			if csiDrivers == nil {
+				csiDrivers = []*storagev1.CSIDriver{testCSIDriver(testCSIDriverName, true)}
+			}
+			csiDriverIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, csiDriver := range csiDrivers {
+				if err := csiDriverIndexer.Add(csiDriver); err != nil {
+					t.Fatalf("failed to add CSIDriver: %v", err)
+				}
+			}
+			analyzer := &conflictAnalyzer{
+				kubeClient:      fake.NewSimpleClientset(objects...),
+				namespaceLister: corelisters.NewNamespaceLister(namespaceIndexer),
+				csiDriverLister: storagelisters.NewCSIDriverLister(csiDriverIndexer),
+			}
+			summary, err := analyzer.analyze(context.TODO())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if summary.SuggestedLabel != suggestedLabel {
+				t.Errorf("unexpected suggested label %q", summary.SuggestedLabel)
+			}
+			if diff := cmp.Diff(tt.expected, summary.Namespaces); diff != "" {
+				t.Errorf("unexpected conflicts:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTopNamespaces(t *testing.T) {
+	summary := &conflictSummary{
+		Namespaces: []namespaceConflicts{
+			{Namespace: "ns1", Pods: []string{"a", "b"}},
+			{Namespace: "ns2", Pods: []string{"a"}},
+			{Namespace: "ns3", Pods: []string{"a"}},
+		},
+	}
+	if got, expected := summary.topNamespaces(2), "ns1 (2 pods), ns2 (1 pod), and 1 more"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if got, expected := summary.topNamespaces(5), "ns1 (2 pods), ns2 (1 pod), ns3 (1 pod)"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestTruncateSummary(t *testing.T) {
+	summary := &conflictSummary{
+		SuggestedLabel: suggestedLabel,
+		Namespaces: []namespaceConflicts{
+			{Namespace: "ns1", Pods: []string{"a", "b", "c"}},
+			{Namespace: "ns2", Pods: []string{"a", "b"}},
+			{Namespace: "ns3", Pods: []string{"a"}},
+		},
+	}
+	expected := &conflictSummary{
+		SuggestedLabel: suggestedLabel,
+		Namespaces: []namespaceConflicts{
+			{Namespace: "ns1", Pods: []string{"a", "b"}, MorePods: 1},
+			{Namespace: "ns2", Pods: []string{"a", "b"}},
+		},
+		MoreNamespaces: 1,
+	}
+	if diff := cmp.Diff(expected, summary.truncate(2, 2)); diff != "" {
+		t.Errorf("unexpected truncated summary:\n%s", diff)
+	}
+	if diff := cmp.Diff(summary, summary.truncate(5, 5)); diff != "" {
+		t.Errorf("expected the summary unchanged:\n%s", diff)
+	}
+}
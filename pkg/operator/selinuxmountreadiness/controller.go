@@ -3,17 +3,23 @@ package selinuxmountreadiness
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	operatorapi "github.com/openshift/api/operator/v1"
 	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 )
 
 const (
@@ -22,34 +28,92 @@ const (
 	selinuxConflictsConfigMapName = "selinux-conflicts"
 	selinuxConflictsDataKey       = "conflictsPresent"
 
+	// adminAcksConfigMapName is the ConfigMap where admins acknowledge upgrade risks.
+	// The controller honours only the key for the current minor version,
+	// e.g. "ack-4.21-selinux-mount-ga": "true".
+	adminAcksConfigMapName = "admin-acks"
+	adminAckKeyFormat      = "ack-%s-selinux-mount-ga"
+
+	// summaryConfigMapName is written by the controller with the list of affected pods.
+	summaryConfigMapName = "selinux-conflicts-summary"
+	summaryDataKey       = "summary.yaml"
+	// messageNamespaces is the number of namespaces listed in the Upgradeable condition message.
+	messageNamespaces = 5
+	// summaryNamespaces and summaryPodsPerNamespace limit the summary ConfigMap, so it stays
+	// well below the object size limit in large clusters.
+	summaryNamespaces       = 100
+	summaryPodsPerNamespace = 50
+	// summaryResync refreshes the summary of conflicting pods. Pods and volumes are not
+	// watched, they are listed only when the analysis is needed and its last result is
+	// older than summaryMaxAge.
+	summaryResync = 10 * time.Minute
+	// summaryMaxAge is shorter than summaryResync, so each resync runs a new analysis.
+	summaryMaxAge = summaryResync - time.Minute
+
 	// KCSArticleURL is linked from Prometheus alerts. Update when the KCS article is published.
 	KCSArticleURL = "https://github.com/openshift/enhancements/blob/master/enhancements/storage/selinuxmount-ga-block-upgrade.md"
 )
 
 // Controller watches openshift-config/selinux-conflicts written by the
 // SELinuxWarningController in kube-controller-manager and sets the storage
-// operator Upgradeable condition accordingly.
+// operator Upgradeable condition accordingly. When the ConfigMap is missing,
+// the conflicts are detected by the operator itself.
+// Admins can accept the risk in openshift-config/admin-acks. The acknowledgment
+// is valid only for the minor version it was given for, keys of other versions
+// are ignored. The ConfigMap is owned by the admin and never written.
+// When conflicts are present, the affected pods are listed in
+// openshift-cluster-storage-operator/selinux-conflicts-summary and, when enabled
+// in openshift-cluster-storage-operator/selinux-remediation, their namespaces are labeled.
 type Controller struct {
 	operatorClient  v1helpers.OperatorClient
+	kubeClient      kubernetes.Interface
 	configMapLister corelisters.ConfigMapNamespaceLister
-	eventRecorder   events.Recorder
+	// operatorConfigMapLister lists ConfigMaps in the operator namespace
+	operatorConfigMapLister corelisters.ConfigMapLister
+	analyzer                *conflictAnalyzer
+	eventRecorder           events.Recorder
+	// minorVersion is the X.Y version of the running operator, e.g. "4.21".
+	minorVersion string
+	clock        clock.PassiveClock
+	// summary is the result of the last analysis, analyzedAt is when it started.
+	summary    *conflictSummary
+	analyzedAt time.Time
 }
 
 func NewController(
 	clients *csoclients.Clients,
+	operatorVersion string,
 	eventRecorder events.Recorder,
 ) factory.Controller {
 	configMapInformer := clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps()
+	summaryInformer := clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps()
+	namespaceInformer := clients.KubeInformers.InformersFor("").Core().V1().Namespaces()
+	csiDriverInformer := clients.KubeInformers.InformersFor("").Storage().V1().CSIDrivers()
 
 	c := &Controller{
-		operatorClient:  clients.OperatorClient,
-		configMapLister: configMapInformer.Lister().ConfigMaps(csoclients.CloudConfigNamespace),
-		eventRecorder:   eventRecorder,
+		operatorClient:          clients.OperatorClient,
+		kubeClient:              clients.KubeClient,
+		configMapLister:         configMapInformer.Lister().ConfigMaps(csoclients.CloudConfigNamespace),
+		operatorConfigMapLister: summaryInformer.Lister(),
+		analyzer: &conflictAnalyzer{
+			kubeClient:      clients.KubeClient,
+			namespaceLister: namespaceInformer.Lister(),
+			csiDriverLister: csiDriverInformer.Lister(),
+		},
+		eventRecorder: eventRecorder,
+		minorVersion:  minorVersion(operatorVersion),
+		clock:         clock.RealClock{},
 	}
+	// Pods and volumes have no informers to keep the operator memory low in large clusters.
+	// They are listed when KCM reports conflicts or does not report at all, at most once
+	// per summaryResync. Syncs in between reuse the last analysis.
 	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(clients.OperatorClient).WithInformers(
 		clients.OperatorClient.Informer(),
 		configMapInformer.Informer(),
-	).ToController("SELinuxMountGAReadinessController", eventRecorder)
+		summaryInformer.Informer(),
+		namespaceInformer.Informer(),
+		csiDriverInformer.Informer(),
+	).ResyncEvery(summaryResync).ToController("SELinuxMountGAReadinessController", eventRecorder)
 }
 
 func (c *Controller) sync(ctx context.Context, _ factory.SyncContext) error {
@@ -73,16 +137,95 @@ func (c *Controller) sync(ctx context.Context, _ factory.SyncContext) error {
 	if err != nil {
 		return err
 	}
-	if found && conflictsPresent {
-		upgradeableCnd.Status = operatorapi.ConditionFalse
-		upgradeableCnd.Reason = "SELinuxMountIncompatibleWorkloads"
-		upgradeableCnd.Message = upgradeBlockedMessage()
+	acknowledged, err := c.adminAcknowledged()
+	if err != nil {
+		return err
+	}
+	var summary *conflictSummary
+	reason := "SELinuxMountIncompatibleWorkloads"
+	if !found || conflictsPresent {
+		summary, err = c.analyze(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		c.summary = nil
+	}
+	if !found {
+		// The SELinuxWarningController is disabled or did not report yet,
+		// do not let the upgrade gate fail open.
+		klog.V(4).Infof("ConfigMap %s/%s not found, using conflicts detected by the operator",
+			csoclients.CloudConfigNamespace, selinuxConflictsConfigMapName)
+		conflictsPresent = len(summary.Namespaces) > 0
+		reason = "SELinuxMountIncompatibleWorkloadsDetectedByOperator"
+	}
+	if err := c.syncSummaryConfigMap(ctx, summary); err != nil {
+		return err
+	}
+	if err := c.remediate(ctx, summary); err != nil {
+		return err
+	}
+
+	if conflictsPresent {
+		if acknowledged {
+			// The SELinuxMountGAReadinessWorkloadsDetected alert keeps firing,
+			// only the upgrade is unblocked.
+			upgradeableCnd.Reason = "AcknowledgedByAdmin"
+			upgradeableCnd.Message = upgradeAcknowledgedMessage(c.adminAckKey())
+		} else {
+			upgradeableCnd.Status = operatorapi.ConditionFalse
+			upgradeableCnd.Reason = reason
+			upgradeableCnd.Message = upgradeBlockedMessage(summary)
+		}
 	}
 
 	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(upgradeableCnd))
 	return err
 }
 
+// analyze returns the conflicting pods. The last result is reused until it is older than
+// summaryMaxAge, the analysis lists all pods and volumes in the cluster.
+func (c *Controller) analyze(ctx context.Context) (*conflictSummary, error) {
+	if c.summary != nil && c.clock.Since(c.analyzedAt) < summaryMaxAge {
+		return c.summary, nil
+	}
+	start := c.clock.Now()
+	summary, err := c.analyzer.analyze(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.summary, c.analyzedAt = summary, start
+	return summary, nil
+}
+
+// syncSummaryConfigMap writes the summary of conflicting pods or removes it when there is none.
+func (c *Controller) syncSummaryConfigMap(ctx context.Context, summary *conflictSummary) error {
+	if summary == nil || len(summary.Namespaces) == 0 {
+		_, err := c.operatorConfigMapLister.ConfigMaps(csoclients.OperatorNamespace).Get(summaryConfigMapName)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return c.kubeClient.CoreV1().ConfigMaps(csoclients.OperatorNamespace).Delete(ctx, summaryConfigMapName, metav1.DeleteOptions{})
+	}
+
+	data, err := yaml.Marshal(summary.truncate(summaryNamespaces, summaryPodsPerNamespace))
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      summaryConfigMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{summaryDataKey: string(data)},
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, cm)
+	return err
+}
+
 func (c *Controller) conflictsPresent() (present bool, found bool, err error) {
 	cm, err := c.configMapLister.Get(selinuxConflictsConfigMapName)
 	if apierrors.IsNotFound(err) {
@@ -95,12 +238,59 @@ func (c *Controller) conflictsPresent() (present bool, found bool, err error) {
 	return present, found, nil
 }
 
-func upgradeBlockedMessage() string {
+// adminAcknowledged returns whether the admin acknowledged the conflicts for the
+// current minor version.
+func (c *Controller) adminAcknowledged() (bool, error) {
+	cm, err := c.configMapLister.Get(adminAcksConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if c.minorVersion == "" {
+		klog.V(2).Infof("Unknown operator version, ignoring ConfigMap %s", adminAcksConfigMapName)
+		return false, nil
+	}
+	return cm.Data[c.adminAckKey()] == "true", nil
+}
+
+func (c *Controller) adminAckKey() string {
+	return fmt.Sprintf(adminAckKeyFormat, c.minorVersion)
+}
+
+// minorVersion returns "X.Y" from the given "X.Y.Z[-suffix]" version or an empty string.
+func minorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func upgradeAcknowledgedMessage(key string) string {
+	return fmt.Sprintf(
+		"Workloads incompatible with SELinuxMount GA were detected and could break after upgrade to the next release. "+
+			"The risk was acknowledged by %s in ConfigMap %s/%s.",
+		key, csoclients.CloudConfigNamespace, adminAcksConfigMapName,
+	)
+}
+
+func upgradeBlockedMessage(summary *conflictSummary) string {
+	if summary == nil || len(summary.Namespaces) == 0 {
+		return fmt.Sprintf(
+			"Workloads incompatible with SELinuxMount GA were detected and could break after upgrade to the next release. "+
+				"See metric selinux_warning_controller_selinux_volume_conflict to list all affected pods. "+
+				"See %s for remediation.",
+			KCSArticleURL,
+		)
+	}
 	return fmt.Sprintf(
 		"Workloads incompatible with SELinuxMount GA were detected and could break after upgrade to the next release. "+
-			"See metric selinux_warning_controller_selinux_volume_conflict to list all affected pods. "+
-			"See %s for remediation.",
-		KCSArticleURL,
+			"Affected namespaces: %s. "+
+			"See ConfigMap %s/%s for the list of affected pods. "+
+			"Label the namespaces with %s or see %s for remediation.",
+		summary.topNamespaces(messageNamespaces), csoclients.OperatorNamespace, summaryConfigMapName, suggestedLabel, KCSArticleURL,
 	)
 }
 
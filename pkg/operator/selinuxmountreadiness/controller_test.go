@@ -11,10 +11,13 @@ import (
 	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
 	clocktesting "k8s.io/utils/clock/testing"
 )
@@ -34,6 +37,17 @@ type operatorTest struct {
 type testObjects struct {
 	storage   *opv1.Storage
 	configMap *corev1.ConfigMap
+	adminAcks *corev1.ConfigMap
+	// workloads are Pods, PVCs, PVs and other objects listed by the controller
+	workloads []runtime.Object
+	summary   *conflictSummary
+}
+
+const testOperatorVersion = "4.21.0-0.nightly-2025-10-01-000000"
+
+var testSummary = &conflictSummary{
+	SuggestedLabel: suggestedLabel,
+	Namespaces:     []namespaceConflicts{{Namespace: "ns1", Pods: []string{"pod1", "pod2"}}},
 }
 
 func newController(test operatorTest) *testContext {
@@ -42,14 +56,20 @@ func newController(test operatorTest) *testContext {
 		initialObjects.OperatorObjects = []runtime.Object{test.initialObjects.storage}
 	}
 	if test.initialObjects.configMap != nil {
-		initialObjects.CoreObjects = []runtime.Object{test.initialObjects.configMap}
+		initialObjects.CoreObjects = append(initialObjects.CoreObjects, test.initialObjects.configMap)
+	}
+	if test.initialObjects.adminAcks != nil {
+		initialObjects.CoreObjects = append(initialObjects.CoreObjects, test.initialObjects.adminAcks)
 	}
+	initialObjects.CoreObjects = append(initialObjects.CoreObjects, test.initialObjects.workloads...)
+	// Volumes of the test workloads support SELinuxMount
+	initialObjects.CoreObjects = append(initialObjects.CoreObjects, testCSIDriver(testCSIDriverName, true))
 
 	clients := csoclients.NewFakeClients(initialObjects)
 	recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
 
 	return &testContext{
-		controller: NewController(clients, recorder),
+		controller: NewController(clients, testOperatorVersion, recorder),
 		clients:    clients,
 	}
 }
@@ -66,6 +86,16 @@ func selinuxConflictsConfigMap(conflictsPresent string) *corev1.ConfigMap {
 	}
 }
 
+func adminAcksConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      adminAcksConfigMapName,
+			Namespace: csoclients.CloudConfigNamespace,
+		},
+		Data: data,
+	}
+}
+
 func withUpgradeableCondition(status opv1.ConditionStatus, reason, message string) csoclients.CrModifier {
 	return func(i *opv1.Storage) *opv1.Storage {
 		if i.Status.Conditions == nil {
@@ -114,8 +144,111 @@ func TestSync(t *testing.T) {
 			},
 			expectedObjects: testObjects{
 				storage: csoclients.GetCR(
-					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloads", upgradeBlockedMessage()),
+					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloads", upgradeBlockedMessage(nil)),
+				),
+			},
+		},
+		{
+			name: "conflicting pods are listed in summary",
+			initialObjects: testObjects{
+				storage:   csoclients.GetCR(),
+				configMap: selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+				workloads: []runtime.Object{
+					testPV("pv1", corev1.ReadWriteMany),
+					boundPVC("ns1", "pvc", "pv1"),
+					podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+					podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+				},
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloads", upgradeBlockedMessage(testSummary)),
+				),
+				summary: testSummary,
+			},
+		},
+		{
+			name: "missing config map uses conflicts detected by operator",
+			initialObjects: testObjects{
+				storage: csoclients.GetCR(),
+				workloads: []runtime.Object{
+					testPV("pv1", corev1.ReadWriteMany),
+					boundPVC("ns1", "pvc", "pv1"),
+					podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+					podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+				},
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloadsDetectedByOperator", upgradeBlockedMessage(testSummary)),
+				),
+				summary: testSummary,
+			},
+		},
+		{
+			name: "missing config map and labeled namespace sets upgradeable true",
+			initialObjects: testObjects{
+				storage: csoclients.GetCR(),
+				workloads: []runtime.Object{
+					testNamespace("ns1", "Recursive"),
+					testPV("pv1", corev1.ReadWriteMany),
+					boundPVC("ns1", "pvc", "pv1"),
+					podWithPVC("ns1", "pod1", "pvc", "s0:c1,c2", ""),
+					podWithPVC("ns1", "pod2", "pvc", "s0:c3,c4", ""),
+				},
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionTrue, "", ""),
+				),
+			},
+		},
+		{
+			name: "acknowledged conflicts set upgradeable true",
+			initialObjects: testObjects{
+				storage:   csoclients.GetCR(),
+				configMap: selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+				adminAcks: adminAcksConfigMap(map[string]string{"ack-4.21-selinux-mount-ga": "true"}),
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionTrue, "AcknowledgedByAdmin", upgradeAcknowledgedMessage("ack-4.21-selinux-mount-ga")),
+				),
+				adminAcks: adminAcksConfigMap(map[string]string{"ack-4.21-selinux-mount-ga": "true"}),
+			},
+		},
+		{
+			name: "acknowledgment of previous version is ignored",
+			initialObjects: testObjects{
+				storage:   csoclients.GetCR(),
+				configMap: selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+				adminAcks: adminAcksConfigMap(map[string]string{
+					"ack-4.20-selinux-mount-ga":               "true",
+					"ack-4.20-kube-1.33-api-removals-in-4.21": "true",
+				}),
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloads", upgradeBlockedMessage(nil)),
 				),
+				adminAcks: adminAcksConfigMap(map[string]string{
+					"ack-4.20-selinux-mount-ga":               "true",
+					"ack-4.20-kube-1.33-api-removals-in-4.21": "true",
+				}),
+			},
+		},
+		{
+			name: "acknowledgment other than true is ignored",
+			initialObjects: testObjects{
+				storage:   csoclients.GetCR(),
+				configMap: selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+				adminAcks: adminAcksConfigMap(map[string]string{"ack-4.21-selinux-mount-ga": "false"}),
+			},
+			expectedObjects: testObjects{
+				storage: csoclients.GetCR(
+					withUpgradeableCondition(opv1.ConditionFalse, "SELinuxMountIncompatibleWorkloads", upgradeBlockedMessage(nil)),
+				),
+				adminAcks: adminAcksConfigMap(map[string]string{"ack-4.21-selinux-mount-ga": "false"}),
 			},
 		},
 		{
@@ -157,8 +290,10 @@ func TestSync(t *testing.T) {
 			defer close(stopCh)
 			csoclients.StartInformers(ctx.clients, stopCh)
 			configMapInformer := ctx.clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps().Informer()
-			if !cache.WaitForCacheSync(stopCh, configMapInformer.HasSynced) {
-				t.Fatal("timed out waiting for openshift-config ConfigMap informer cache sync")
+			namespaceInformer := ctx.clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Informer()
+			csiDriverInformer := ctx.clients.KubeInformers.InformersFor("").Storage().V1().CSIDrivers().Informer()
+			if !cache.WaitForCacheSync(stopCh, configMapInformer.HasSynced, namespaceInformer.HasSynced, csiDriverInformer.HasSynced) {
+				t.Fatal("timed out waiting for informer cache sync")
 			}
 
 			err := ctx.controller.Sync(context.TODO(), nil)
@@ -178,6 +313,94 @@ func TestSync(t *testing.T) {
 			if !equality.Semantic.DeepEqual(test.expectedObjects.storage.Status.OperatorStatus, *status) {
 				t.Fatalf("unexpected Storage status:\n%s", cmp.Diff(test.expectedObjects.storage.Status.OperatorStatus, *status))
 			}
+
+			summaryCM, err := ctx.clients.KubeClient.CoreV1().ConfigMaps(csoclients.OperatorNamespace).Get(context.TODO(), summaryConfigMapName, metav1.GetOptions{})
+			if test.expectedObjects.summary == nil {
+				if !apierrors.IsNotFound(err) {
+					t.Fatalf("expected no ConfigMap %s, got: %v", summaryConfigMapName, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("failed to get ConfigMap %s: %v", summaryConfigMapName, err)
+				}
+				summary := &conflictSummary{}
+				if err := yaml.Unmarshal([]byte(summaryCM.Data[summaryDataKey]), summary); err != nil {
+					t.Fatalf("failed to parse ConfigMap %s: %v", summaryConfigMapName, err)
+				}
+				if !equality.Semantic.DeepEqual(test.expectedObjects.summary, summary) {
+					t.Fatalf("unexpected summary:\n%s", cmp.Diff(test.expectedObjects.summary, summary))
+				}
+			}
+
+			if test.expectedObjects.adminAcks != nil {
+				acks, err := ctx.clients.KubeClient.CoreV1().ConfigMaps(csoclients.CloudConfigNamespace).Get(context.TODO(), adminAcksConfigMapName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get ConfigMap %s: %v", adminAcksConfigMapName, err)
+				}
+				if !equality.Semantic.DeepEqual(test.expectedObjects.adminAcks.Data, acks.Data) {
+					t.Fatalf("unexpected ConfigMap %s data:\n%s", adminAcksConfigMapName, cmp.Diff(test.expectedObjects.adminAcks.Data, acks.Data))
+				}
+			}
+		})
+	}
+}
+
+func TestSyncListsPodsOnlyWhenNeeded(t *testing.T) {
+	tests := []struct {
+		name       string
+		configMap  *corev1.ConfigMap
+		expectList bool
+	}{
+		{
+			name:      "no conflicts reported",
+			configMap: selinuxConflictsConfigMap(string(metav1.ConditionFalse)),
+		},
+		{
+			name:       "conflicts reported",
+			configMap:  selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+			expectList: true,
+		},
+		{
+			name:       "missing config map",
+			expectList: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newController(operatorTest{
+				initialObjects: testObjects{storage: csoclients.GetCR(), configMap: tt.configMap},
+			})
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(ctx.clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				ctx.clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				ctx.clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			// The second sync reuses the analysis of the first one
+			for i := 0; i < 2; i++ {
+				if err := ctx.controller.Sync(context.TODO(), nil); err != nil {
+					t.Fatalf("sync() returned unexpected error: %v", err)
+				}
+			}
+
+			lists := 0
+			for _, action := range ctx.clients.KubeClient.(*fake.Clientset).Actions() {
+				if action.Matches("list", "pods") {
+					lists++
+				}
+			}
+			expectedLists := 0
+			if tt.expectList {
+				expectedLists = 1
+			}
+			if lists != expectedLists {
+				t.Errorf("expected pods listed %d times, got %d", expectedLists, lists)
+			}
 		})
 	}
 }
@@ -239,6 +462,21 @@ func TestConflictsPresentInConfigMap(t *testing.T) {
 	}
 }
 
+func TestMinorVersion(t *testing.T) {
+	tests := map[string]string{
+		"4.21.0":                             "4.21",
+		"4.21.0-0.nightly-2025-10-01-000000": "4.21",
+		"4.21":                               "4.21",
+		"":                                   "",
+		"4":                                  "",
+	}
+	for version, expected := range tests {
+		if got := minorVersion(version); got != expected {
+			t.Errorf("minorVersion(%q) = %q, want %q", version, got, expected)
+		}
+	}
+}
+
 func sanitizeStatus(status *opv1.OperatorStatus) {
 	for i := range status.Conditions {
 		status.Conditions[i].LastTransitionTime = metav1.Time{}
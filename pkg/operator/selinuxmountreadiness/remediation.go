@@ -0,0 +1,156 @@
+package selinuxmountreadiness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// remediationConfigMapName is an optional ConfigMap in the CSO namespace with remediationConfig.
+	remediationConfigMapName = "selinux-remediation"
+
+	// auditConfigMapName records namespaces labeled by the operator, one key per namespace
+	// with the time of the change.
+	auditConfigMapName = "selinux-remediation-audit"
+	// auditDryRunKey lists namespaces that would be labeled in DryRun mode.
+	// Namespace names cannot contain upper case letters, so the key does not clash with them.
+	auditDryRunKey = "dryRun"
+)
+
+type remediationMode string
+
+const (
+	// remediationDisabled is the default, namespaces are not labeled.
+	remediationDisabled remediationMode = "Disabled"
+	// remediationDryRun lists the namespaces that would be labeled in the audit ConfigMap.
+	remediationDryRun remediationMode = "DryRun"
+	// remediationEnabled labels the namespaces.
+	remediationEnabled remediationMode = "Enabled"
+)
+
+// remediationConfig is the content of selinux-remediation ConfigMap.
+type remediationConfig struct {
+	Mode remediationMode `yaml:"mode,omitempty"`
+}
+
+// excludedNamespacePrefixes are namespaces of the platform, their owners fix the conflicts.
+var excludedNamespacePrefixes = []string{"openshift-", "kube-"}
+
+func (c *Controller) parseRemediationConfig() (*remediationConfig, error) {
+	cfg := &remediationConfig{Mode: remediationDisabled}
+	if _, err := csoutils.ParseOperatorConfigMap(c.operatorConfigMapLister, remediationConfigMapName, cfg); err != nil {
+		return nil, err
+	}
+	switch cfg.Mode {
+	case remediationDisabled, remediationDryRun, remediationEnabled:
+	case "":
+		cfg.Mode = remediationDisabled
+	default:
+		return nil, fmt.Errorf("invalid config in ConfigMap %s: unknown mode %q, expected one of %s, %s, %s",
+			remediationConfigMapName, cfg.Mode, remediationDisabled, remediationDryRun, remediationEnabled)
+	}
+	return cfg, nil
+}
+
+// remediate labels namespaces with conflicting pods with suggestedLabel, when enabled
+// in the selinux-remediation ConfigMap. Namespaces with an explicit label are left untouched,
+// a label set from the cluster-wide default storage performant policy is not explicit.
+func (c *Controller) remediate(ctx context.Context, summary *conflictSummary) error {
+	cfg, err := c.parseRemediationConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Mode == remediationDisabled {
+		return nil
+	}
+
+	var namespaces []string
+	if summary != nil {
+		for _, nsConflicts := range summary.Namespaces {
+			if c.needsRemediation(nsConflicts.Namespace) {
+				namespaces = append(namespaces, nsConflicts.Namespace)
+			}
+		}
+	}
+	sort.Strings(namespaces)
+
+	audit, err := c.operatorConfigMapLister.ConfigMaps(csoclients.OperatorNamespace).Get(auditConfigMapName)
+	if apierrors.IsNotFound(err) {
+		audit = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      auditConfigMapName,
+				Namespace: csoclients.OperatorNamespace,
+			},
+		}
+	} else if err != nil {
+		return err
+	}
+	audit = audit.DeepCopy()
+	if audit.Data == nil {
+		audit.Data = map[string]string{}
+	}
+
+	if cfg.Mode == remediationDryRun {
+		dryRun := strings.Join(namespaces, "\n")
+		if audit.Data[auditDryRunKey] != dryRun && len(namespaces) > 0 {
+			c.eventRecorder.Eventf("SELinuxChangePolicyLabelDryRun", "Namespaces that would be labeled with %s: %s",
+				suggestedLabel, strings.Join(namespaces, ", "))
+		}
+		audit.Data[auditDryRunKey] = dryRun
+		_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, audit)
+		return err
+	}
+
+	_, hadDryRun := audit.Data[auditDryRunKey]
+	if len(namespaces) == 0 && !hadDryRun {
+		return nil
+	}
+	delete(audit.Data, auditDryRunKey)
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, selinuxChangePolicyLabel, corev1.SELinuxChangePolicyRecursive))
+	var errs []error
+	for _, namespace := range namespaces {
+		klog.V(2).Infof("Labeling namespace %s with %s", namespace, suggestedLabel)
+		if _, err := c.kubeClient.CoreV1().Namespaces().Patch(ctx, namespace, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			c.eventRecorder.Warningf("SELinuxChangePolicyLabelFailed", "Failed to label namespace %s with %s: %s", namespace, suggestedLabel, err)
+			errs = append(errs, err)
+			continue
+		}
+		c.eventRecorder.Eventf("SELinuxChangePolicyLabelAdded", "Labeled namespace %s with %s to resolve SELinuxMount GA conflicts, restart its pods to apply it",
+			namespace, suggestedLabel)
+		audit.Data[namespace] = time.Now().UTC().Format(time.RFC3339)
+	}
+	// Record the namespaces labeled so far even when some failed
+	if _, _, err := resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, audit); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *Controller) needsRemediation(namespace string) bool {
+	for _, prefix := range excludedNamespacePrefixes {
+		if strings.HasPrefix(namespace, prefix) {
+			return false
+		}
+	}
+	ns, err := c.analyzer.namespaceLister.Get(namespace)
+	if err != nil {
+		// Deleted namespace
+		return false
+	}
+	_, labeled := ns.Labels[selinuxChangePolicyLabel]
+	return !labeled || performantpolicy.IsDefaultLabel(ns, selinuxChangePolicyLabel)
+}
@@ -0,0 +1,178 @@
+package selinuxmountreadiness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/performantpolicy"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func remediationConfigMap(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      remediationConfigMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+// conflictingWorkloads returns objects with two conflicting pods in the given namespace.
+func conflictingWorkloads(ns *corev1.Namespace) []runtime.Object {
+	pvName := "pv-" + ns.Name
+	return []runtime.Object{
+		ns,
+		testPV(pvName, corev1.ReadWriteMany),
+		boundPVC(ns.Name, "pvc", pvName),
+		podWithPVC(ns.Name, "pod1", "pvc", "s0:c1,c2", corev1.SELinuxChangePolicyMountOption),
+		podWithPVC(ns.Name, "pod2", "pvc", "s0:c3,c4", corev1.SELinuxChangePolicyMountOption),
+	}
+}
+
+// withDefaultLabel marks the policy label of the namespace as set from the cluster-wide default.
+func withDefaultLabel(ns *corev1.Namespace) *corev1.Namespace {
+	ns.Annotations = map[string]string{
+		performantpolicy.DefaultLabelsAnnotation: fmt.Sprintf(`{%q:%q}`, selinuxChangePolicyLabel, ns.Labels[selinuxChangePolicyLabel]),
+	}
+	return ns
+}
+
+func TestRemediation(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            *corev1.ConfigMap
+		namespaces        []*corev1.Namespace
+		expectErr         string
+		expectedLabeled   []string
+		expectedDryRun    string
+		expectedNoAuditCM bool
+	}{
+		{
+			name:              "disabled by default",
+			namespaces:        []*corev1.Namespace{testNamespace("ns1", "")},
+			expectedNoAuditCM: true,
+		},
+		{
+			name:           "dry run",
+			config:         remediationConfigMap("mode: DryRun\n"),
+			namespaces:     []*corev1.Namespace{testNamespace("ns1", ""), testNamespace("ns2", "")},
+			expectedDryRun: "ns1\nns2",
+		},
+		{
+			name:            "enabled",
+			config:          remediationConfigMap("mode: Enabled\n"),
+			namespaces:      []*corev1.Namespace{testNamespace("ns1", "")},
+			expectedLabeled: []string{"ns1"},
+		},
+		{
+			name:   "enabled skips labeled and platform namespaces",
+			config: remediationConfigMap("mode: Enabled\n"),
+			namespaces: []*corev1.Namespace{
+				testNamespace("ns1", ""),
+				testNamespace("ns2", string(corev1.SELinuxChangePolicyMountOption)),
+				testNamespace("openshift-foo", ""),
+			},
+			expectedLabeled: []string{"ns1"},
+		},
+		{
+			name:   "enabled relabels namespaces with the default policy label",
+			config: remediationConfigMap("mode: Enabled\n"),
+			namespaces: []*corev1.Namespace{
+				withDefaultLabel(testNamespace("ns1", string(corev1.SELinuxChangePolicyMountOption))),
+			},
+			expectedLabeled: []string{"ns1"},
+		},
+		{
+			name:       "invalid mode",
+			config:     remediationConfigMap("mode: Always\n"),
+			namespaces: []*corev1.Namespace{testNamespace("ns1", "")},
+			expectErr:  `unknown mode "Always"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := operatorTest{
+				initialObjects: testObjects{
+					storage:   csoclients.GetCR(),
+					configMap: selinuxConflictsConfigMap(string(metav1.ConditionTrue)),
+				},
+			}
+			if tt.config != nil {
+				test.initialObjects.workloads = append(test.initialObjects.workloads, tt.config)
+			}
+			for _, ns := range tt.namespaces {
+				test.initialObjects.workloads = append(test.initialObjects.workloads, conflictingWorkloads(ns)...)
+			}
+			ctx := newController(test)
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(ctx.clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				ctx.clients.KubeInformers.InformersFor(csoclients.CloudConfigNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				ctx.clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				ctx.clients.KubeInformers.InformersFor("").Core().V1().Namespaces().Informer().HasSynced,
+				ctx.clients.KubeInformers.InformersFor("").Storage().V1().CSIDrivers().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctx.controller.Sync(context.TODO(), nil)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expected error %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+
+			labeled := map[string]bool{}
+			for _, name := range tt.expectedLabeled {
+				labeled[name] = true
+			}
+			for _, expected := range tt.namespaces {
+				ns, err := ctx.clients.KubeClient.CoreV1().Namespaces().Get(context.TODO(), expected.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get namespace %s: %v", expected.Name, err)
+				}
+				policy := ns.Labels[selinuxChangePolicyLabel]
+				if labeled[ns.Name] && policy != string(corev1.SELinuxChangePolicyRecursive) {
+					t.Errorf("expected namespace %s to be labeled, got labels %v", ns.Name, ns.Labels)
+				}
+				if !labeled[ns.Name] && policy != expected.Labels[selinuxChangePolicyLabel] {
+					t.Errorf("expected namespace %s not to be labeled, got labels %v", ns.Name, ns.Labels)
+				}
+			}
+
+			audit, err := ctx.clients.KubeClient.CoreV1().ConfigMaps(csoclients.OperatorNamespace).Get(context.TODO(), auditConfigMapName, metav1.GetOptions{})
+			if tt.expectedNoAuditCM {
+				if !apierrors.IsNotFound(err) {
+					t.Fatalf("expected no ConfigMap %s, got: %v", auditConfigMapName, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get ConfigMap %s: %v", auditConfigMapName, err)
+			}
+			if audit.Data[auditDryRunKey] != tt.expectedDryRun {
+				t.Errorf("expected dry run %q, got %q", tt.expectedDryRun, audit.Data[auditDryRunKey])
+			}
+			for _, name := range tt.expectedLabeled {
+				if _, found := audit.Data[name]; !found {
+					t.Errorf("expected namespace %s in audit ConfigMap, got %v", name, audit.Data)
+				}
+			}
+		})
+	}
+}
@@ -16,7 +16,7 @@ const (
 	clusterOperatorName = "storage"
 )
 
-func RunOperator(ctx context.Context, controllerConfig *controllercmd.ControllerContext, guestKubeConfig *string) error {
+func RunOperator(ctx context.Context, controllerConfig *controllercmd.ControllerContext, guestKubeConfig *string, hostedControlPlaneName *string) error {
 	isHyperShift := false
 	if guestKubeConfig != nil && *guestKubeConfig != "" {
 		isHyperShift = true
@@ -25,7 +25,11 @@ func RunOperator(ctx context.Context, controllerConfig *controllercmd.Controller
 	starter := NewStandaloneStarter(controllerConfig)
 
 	if isHyperShift {
-		starter = NewHyperShiftStarter(controllerConfig, *guestKubeConfig)
+		hcpName := ""
+		if hostedControlPlaneName != nil {
+			hcpName = *hostedControlPlaneName
+		}
+		starter = NewHyperShiftStarter(controllerConfig, *guestKubeConfig, hcpName)
 	}
 	return starter.StartOperator(ctx)
 }
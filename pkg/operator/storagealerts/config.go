@@ -0,0 +1,113 @@
+package storagealerts
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// configMapName is an optional ConfigMap in the CSO namespace with AlertsConfig.
+	configMapName = "storage-alerts"
+
+	day = 24 * time.Hour
+)
+
+// AlertsConfig tunes the PersistentVolume alerts. Empty fields use the defaults.
+type AlertsConfig struct {
+	// CriticalAvailablePercent fires critical KubePersistentVolume(Inodes)FillingUp alerts
+	// when less than this percentage of the volume is available.
+	CriticalAvailablePercent float64 `yaml:"criticalAvailablePercent,omitempty"`
+	// WarningAvailablePercent fires warning KubePersistentVolume(Inodes)FillingUp alerts
+	// when less than this percentage is available and the volume is predicted to fill up
+	// within PredictionWindow.
+	WarningAvailablePercent float64       `yaml:"warningAvailablePercent,omitempty"`
+	PredictionWindow        time.Duration `yaml:"predictionWindow,omitempty"`
+	// NamespaceRegex selects namespaces of PVCs covered by the alerts.
+	NamespaceRegex string `yaml:"namespaceRegex,omitempty"`
+}
+
+var defaultConfig = AlertsConfig{
+	CriticalAvailablePercent: 3,
+	WarningAvailablePercent:  15,
+	PredictionWindow:         4 * day,
+	NamespaceRegex:           "(openshift-.*|kube-.*|default)",
+}
+
+// parseConfigMap returns the alerts config from the storage-alerts ConfigMap,
+// filled with defaults.
+func parseConfigMap(lister listerv1.ConfigMapLister) (*AlertsConfig, error) {
+	config := defaultConfig
+	found, err := csoutils.ParseOperatorConfigMap(lister, configMapName, &config)
+	if err != nil || !found {
+		return &config, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config in ConfigMap %s: %s", configMapName, err)
+	}
+	klog.V(4).Infof("Parsed ConfigMap %s: %+v", configMapName, config)
+	return &config, nil
+}
+
+func (c *AlertsConfig) validate() error {
+	for name, percent := range map[string]float64{
+		"criticalAvailablePercent": c.CriticalAvailablePercent,
+		"warningAvailablePercent":  c.WarningAvailablePercent,
+	} {
+		if percent <= 0 || percent >= 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %v", name, percent)
+		}
+	}
+	if c.CriticalAvailablePercent >= c.WarningAvailablePercent {
+		return fmt.Errorf("criticalAvailablePercent must be lower than warningAvailablePercent")
+	}
+	if c.PredictionWindow < time.Hour {
+		return fmt.Errorf("predictionWindow must be at least 1h, got %s", c.PredictionWindow)
+	}
+	if c.PredictionWindow%time.Second != 0 {
+		return fmt.Errorf("predictionWindow must be a whole number of seconds, got %s", c.PredictionWindow)
+	}
+	// Prometheus uses the same RE2 syntax as Go.
+	if _, err := regexp.Compile(c.NamespaceRegex); err != nil {
+		return fmt.Errorf("invalid namespaceRegex: %s", err)
+	}
+	return nil
+}
+
+// replacer returns a replacer of the placeholders in the PrometheusRule asset.
+func (c *AlertsConfig) replacer() *strings.Replacer {
+	return strings.NewReplacer(
+		// PromQL strings use Go escaping rules
+		"${NAMESPACE_REGEX}", strconv.Quote(c.NamespaceRegex),
+		"${CRITICAL_THRESHOLD}", formatRatio(c.CriticalAvailablePercent),
+		"${WARNING_THRESHOLD}", formatRatio(c.WarningAvailablePercent),
+		"${PREDICTION_WINDOW_SECONDS}", strconv.FormatInt(int64(c.PredictionWindow/time.Second), 10),
+		"${PREDICTION_WINDOW}", formatWindow(c.PredictionWindow),
+	)
+}
+
+func formatRatio(percent float64) string {
+	return strconv.FormatFloat(percent/100, 'f', -1, 64)
+}
+
+// formatWindow returns human readable prediction window for alert descriptions.
+func formatWindow(window time.Duration) string {
+	switch {
+	case window == day:
+		return "one day"
+	case window%day == 0:
+		return fmt.Sprintf("%d days", window/day)
+	case window == time.Hour:
+		return "one hour"
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%d hours", window/time.Hour)
+	default:
+		return window.String()
+	}
+}
@@ -0,0 +1,96 @@
+package storagealerts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/prometheusrule"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	promclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	controllerName      = "StorageAlertsController"
+	prometheusRuleAsset = "storagealerts/prometheusrule.yaml"
+)
+
+// Controller renders the PersistentVolume alerts from the storage-alerts ConfigMap.
+// An invalid config is reported in StorageAlertsControllerDegraded condition and the
+// previously applied rule is kept.
+type Controller struct {
+	operatorClient   v1helpers.OperatorClient
+	monitoringClient promclient.Interface
+	configMapLister  listerv1.ConfigMapLister
+	eventRecorder    events.Recorder
+}
+
+func NewController(
+	clients *csoclients.Clients,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+	c := &Controller{
+		operatorClient:   clients.OperatorClient,
+		monitoringClient: clients.MonitoringClient,
+		configMapLister:  clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+		eventRecorder:    eventRecorder.WithComponentSuffix("storage-alerts-controller"),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			c.operatorClient.Informer(),
+			clients.MonitoringInformer.Monitoring().V1().PrometheusRules().Informer(),
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(controllerName, c.eventRecorder)
+}
+
+func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("StorageAlertsController sync started")
+	defer klog.V(4).Infof("StorageAlertsController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	cfg, err := parseConfigMap(c.configMapLister)
+	if err != nil {
+		return err
+	}
+	rule, err := renderPrometheusRule(cfg)
+	if err != nil {
+		return err
+	}
+	_, _, err = prometheusrule.ApplyPrometheusRule(ctx, c.monitoringClient, c.eventRecorder, rule)
+	return err
+}
+
+func renderPrometheusRule(cfg *AlertsConfig) (*promv1.PrometheusRule, error) {
+	ruleBytes, err := assets.ReadFile(prometheusRuleAsset)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := prometheusrule.ReadPrometheusRule([]byte(cfg.replacer().Replace(string(ruleBytes))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", prometheusRuleAsset, err)
+	}
+	return rule, nil
+}
@@ -0,0 +1,176 @@
+package storagealerts
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/events"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func getCM(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+// alertExpr returns expression of the alert with the given name and severity.
+func alertExpr(rule *promv1.PrometheusRule, alert, severity string) string {
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			if r.Alert == alert && r.Labels["severity"] == severity {
+				return r.Expr.String()
+			}
+		}
+	}
+	return ""
+}
+
+func alertDescription(rule *promv1.PrometheusRule, alert, severity string) string {
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			if r.Alert == alert && r.Labels["severity"] == severity {
+				return r.Annotations["description"]
+			}
+		}
+	}
+	return ""
+}
+
+func TestController(t *testing.T) {
+	tests := []struct {
+		name                string
+		configMap           *corev1.ConfigMap
+		expectErr           string
+		expectedCritical    []string
+		expectedWarning     []string
+		expectedDescription string
+	}{
+		{
+			name: "default config",
+			expectedCritical: []string{
+				`kubelet_volume_stats_available_bytes{namespace=~"(openshift-.*|kube-.*|default)",job="kubelet", metrics_path="/metrics"}`,
+				") < 0.03\n",
+			},
+			expectedWarning: []string{
+				") < 0.15\n",
+				"[6h], 345600) < 0",
+			},
+			expectedDescription: "is expected to fill up within 4 days.",
+		},
+		{
+			name: "custom config",
+			configMap: getCM(`
+criticalAvailablePercent: 5
+warningAvailablePercent: 20.5
+predictionWindow: 36h
+namespaceRegex: (openshift-.*|kube-.*|default|infra-.*)
+`),
+			expectedCritical: []string{
+				`kubelet_volume_stats_available_bytes{namespace=~"(openshift-.*|kube-.*|default|infra-.*)",job="kubelet", metrics_path="/metrics"}`,
+				") < 0.05\n",
+			},
+			expectedWarning: []string{
+				") < 0.205\n",
+				"[6h], 129600) < 0",
+			},
+			expectedDescription: "is expected to fill up within 36 hours.",
+		},
+		{
+			name:      "invalid threshold",
+			configMap: getCM("criticalAvailablePercent: 100\n"),
+			expectErr: "criticalAvailablePercent must be between 0 and 100",
+		},
+		{
+			name:      "critical above warning",
+			configMap: getCM("criticalAvailablePercent: 20\n"),
+			expectErr: "criticalAvailablePercent must be lower than warningAvailablePercent",
+		},
+		{
+			name:      "short prediction window",
+			configMap: getCM("predictionWindow: 10m\n"),
+			expectErr: "predictionWindow must be at least 1h",
+		},
+		{
+			name:      "invalid regex",
+			configMap: getCM("namespaceRegex: (infra-.*\n"),
+			expectErr: "invalid namespaceRegex",
+		},
+		{
+			name:      "unknown field",
+			configMap: getCM("namespaces: infra-.*\n"),
+			expectErr: "invalid format of ConfigMap storage-alerts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var coreObjects []runtime.Object
+			if tt.configMap != nil {
+				coreObjects = append(coreObjects, tt.configMap)
+			}
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     coreObjects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+			})
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewController(clients, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctrl.Sync(context.TODO(), nil)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expected error %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rule, err := clients.MonitoringClient.MonitoringV1().PrometheusRules(csoclients.OperatorNamespace).Get(context.TODO(), "kubernetes-storage", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PrometheusRule: %v", err)
+			}
+			critical := alertExpr(rule, "KubePersistentVolumeFillingUp", "critical")
+			for _, expected := range tt.expectedCritical {
+				if !strings.Contains(critical, expected) {
+					t.Errorf("expected critical alert to contain %q, got:\n%s", expected, critical)
+				}
+			}
+			warning := alertExpr(rule, "KubePersistentVolumeFillingUp", "warning")
+			for _, expected := range tt.expectedWarning {
+				if !strings.Contains(warning, expected) {
+					t.Errorf("expected warning alert to contain %q, got:\n%s", expected, warning)
+				}
+			}
+			if description := alertDescription(rule, "KubePersistentVolumeFillingUp", "warning"); !strings.Contains(description, tt.expectedDescription) {
+				t.Errorf("expected description to contain %q, got: %s", tt.expectedDescription, description)
+			}
+			if inodes := alertExpr(rule, "KubePersistentVolumeInodesFillingUp", "warning"); strings.Contains(inodes, "${") {
+				t.Errorf("unreplaced placeholder in:\n%s", inodes)
+			}
+		})
+	}
+}
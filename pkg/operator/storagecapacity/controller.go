@@ -0,0 +1,136 @@
+package storagecapacity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/prometheusrule"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	promclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	controllerName      = "StorageCapacityController"
+	prometheusRuleAsset = "storagecapacity/01_prometheusrule.yaml"
+	dashboardAsset      = "storagecapacity/02_dashboard.yaml"
+
+	// configMapName is an optional ConfigMap in the CSO namespace with capacityConfig.
+	configMapName = "storage-capacity"
+)
+
+// capacityConfig is the content of storage-capacity ConfigMap.
+type capacityConfig struct {
+	// DashboardEnabled adds "Storage / Capacity by StorageClass" dashboard to the console.
+	DashboardEnabled bool `yaml:"dashboardEnabled,omitempty"`
+}
+
+// Controller applies recording rules with PersistentVolume capacity per StorageClass
+// and, when enabled, a console dashboard that shows them.
+type Controller struct {
+	operatorClient   v1helpers.OperatorClient
+	kubeClient       kubernetes.Interface
+	monitoringClient promclient.Interface
+	configMapLister  listerv1.ConfigMapLister
+	// dashboardLister lists ConfigMaps in openshift-config-managed
+	dashboardLister listerv1.ConfigMapLister
+	eventRecorder   events.Recorder
+}
+
+func NewController(
+	clients *csoclients.Clients,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder) factory.Controller {
+	c := &Controller{
+		operatorClient:   clients.OperatorClient,
+		kubeClient:       clients.KubeClient,
+		monitoringClient: clients.MonitoringClient,
+		configMapLister:  clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+		dashboardLister:  clients.KubeInformers.InformersFor(csoclients.ManagedConfigNamespace).Core().V1().ConfigMaps().Lister(),
+		eventRecorder:    eventRecorder.WithComponentSuffix("storage-capacity-controller"),
+	}
+	return factory.New().
+		WithSync(c.sync).
+		WithSyncDegradedOnError(clients.OperatorClient).
+		WithInformers(
+			c.operatorClient.Informer(),
+			clients.MonitoringInformer.Monitoring().V1().PrometheusRules().Informer(),
+			clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer(),
+			clients.KubeInformers.InformersFor(csoclients.ManagedConfigNamespace).Core().V1().ConfigMaps().Informer(),
+		).
+		ResyncEvery(resyncInterval).
+		ToController(controllerName, c.eventRecorder)
+}
+
+func (c *Controller) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("StorageCapacityController sync started")
+	defer klog.V(4).Infof("StorageCapacityController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	ruleBytes, err := assets.ReadFile(prometheusRuleAsset)
+	if err != nil {
+		return err
+	}
+	rule, err := prometheusrule.ReadPrometheusRule(ruleBytes)
+	if err != nil {
+		return fmt.Errorf("invalid asset %s: %w", prometheusRuleAsset, err)
+	}
+	if _, _, err := prometheusrule.ApplyPrometheusRule(ctx, c.monitoringClient, c.eventRecorder, rule); err != nil {
+		return err
+	}
+
+	cfg, err := c.parseConfigMap()
+	if err != nil {
+		return err
+	}
+	dashboardBytes, err := assets.ReadFile(dashboardAsset)
+	if err != nil {
+		return err
+	}
+	dashboard := resourceread.ReadConfigMapV1OrDie(dashboardBytes)
+	if cfg.DashboardEnabled {
+		_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, dashboard)
+		return err
+	}
+
+	_, err = c.dashboardLister.ConfigMaps(dashboard.Namespace).Get(dashboard.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, _, err = resourceapply.DeleteConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, dashboard)
+	return err
+}
+
+func (c *Controller) parseConfigMap() (*capacityConfig, error) {
+	// The dashboard is disabled by default
+	cfg := &capacityConfig{}
+	if _, err := csoutils.ParseOperatorConfigMap(c.configMapLister, configMapName, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,132 @@
+package storagecapacity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	csoutils "github.com/openshift/cluster-storage-operator/pkg/utils"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func getCM(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: csoclients.OperatorNamespace,
+		},
+		Data: map[string]string{csoutils.OperatorConfigKey: config},
+	}
+}
+
+func existingDashboard() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dashboard-storage-capacity",
+			Namespace: csoclients.ManagedConfigNamespace,
+		},
+	}
+}
+
+func TestController(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		expectErr       bool
+		expectDashboard bool
+	}{
+		{
+			name: "default config",
+		},
+		{
+			name:            "dashboard enabled",
+			objects:         []runtime.Object{getCM("dashboardEnabled: true\n")},
+			expectDashboard: true,
+		},
+		{
+			name:    "dashboard disabled",
+			objects: []runtime.Object{getCM("dashboardEnabled: false\n"), existingDashboard()},
+		},
+		{
+			name:      "invalid config",
+			objects:   []runtime.Object{getCM("dashboard: true\n")},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     tt.objects,
+				OperatorObjects: []runtime.Object{csoclients.GetCR()},
+			})
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl := NewController(clients, time.Hour, recorder)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			if !cache.WaitForCacheSync(stopCh,
+				clients.KubeInformers.InformersFor(csoclients.OperatorNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+				clients.KubeInformers.InformersFor(csoclients.ManagedConfigNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+			) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			err := ctrl.Sync(context.TODO(), nil)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rule, err := clients.MonitoringClient.MonitoringV1().PrometheusRules(csoclients.OperatorNamespace).Get(context.TODO(), "storage-capacity", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PrometheusRule: %v", err)
+			}
+			records := map[string]bool{}
+			for _, r := range rule.Spec.Groups[0].Rules {
+				records[r.Record] = true
+			}
+			for _, record := range []string{
+				"storageclass:kubelet_volume_stats_used_bytes:sum",
+				"storageclass:kubelet_volume_stats_available_bytes:sum",
+				"storageclass:kube_persistentvolume_capacity_bytes:sum",
+				"storageclass:kube_persistentvolumeclaim_info:count",
+			} {
+				if !records[record] {
+					t.Errorf("missing recording rule %s", record)
+				}
+			}
+
+			dashboard, err := clients.KubeClient.CoreV1().ConfigMaps(csoclients.ManagedConfigNamespace).Get(context.TODO(), "dashboard-storage-capacity", metav1.GetOptions{})
+			if !tt.expectDashboard {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected no dashboard, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get dashboard: %v", err)
+			}
+			if dashboard.Labels["console.openshift.io/dashboard"] != "true" {
+				t.Errorf("dashboard is missing console label: %v", dashboard.Labels)
+			}
+			if !json.Valid([]byte(dashboard.Data["storage-capacity.json"])) {
+				t.Errorf("dashboard is not a valid JSON")
+			}
+		})
+	}
+}
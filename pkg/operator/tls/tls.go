@@ -4,41 +4,71 @@ import (
 	"fmt"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	sigsyaml "sigs.k8s.io/yaml"
 )
 
-// TLSSettingsFromProfile returns minTLSVersion and IANA cipher suite names from a TLS
-// security profile, defaulting to Intermediate if nil, empty, or unknown.
-func TLSSettingsFromProfile(profile *configv1.TLSSecurityProfile) (string, []string) {
+const (
+	// OperatorConfigHashAnnotation is set on a Deployment and its pod template to the hash
+	// of the operator config ConfigMap, so pods are restarted when the TLS profile changes.
+	OperatorConfigHashAnnotation = "storage.openshift.io/operator-config-hash"
+
+	// TLSProfileProgressingConditionSuffix is appended to a component's condition prefix
+	// to report that the component still runs pods with the previous TLS profile.
+	TLSProfileProgressingConditionSuffix = "TLSProfileProgressing"
+)
+
+// TLSSettings are the TLS settings of a TLS security profile, in the form consumed by
+// the generated GenericOperatorConfig. Key exchange groups of the profile are not included,
+// configv1.ServingInfo has no field for them, so the operands use their defaults.
+type TLSSettings struct {
+	MinTLSVersion string
+	// CipherSuites are IANA cipher suite names.
+	CipherSuites []string
+}
+
+// TLSSettingsFromProfile returns TLS settings of a TLS security profile, defaulting to
+// Intermediate if nil, empty, or unknown.
+func TLSSettingsFromProfile(profile *configv1.TLSSecurityProfile) TLSSettings {
 	if profile == nil || profile.Type == "" {
-		spec := configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
-		return string(spec.MinTLSVersion), crypto.OpenSSLToIANACipherSuites(spec.Ciphers)
+		return tlsSettingsFromSpec(configv1.TLSProfiles[configv1.TLSProfileIntermediateType])
 	}
 	if profile.Type == configv1.TLSProfileCustomType {
 		if profile.Custom == nil {
-			spec := configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
-			return string(spec.MinTLSVersion), crypto.OpenSSLToIANACipherSuites(spec.Ciphers)
+			return tlsSettingsFromSpec(configv1.TLSProfiles[configv1.TLSProfileIntermediateType])
 		}
-		return string(profile.Custom.MinTLSVersion), crypto.OpenSSLToIANACipherSuites(profile.Custom.Ciphers)
+		return tlsSettingsFromSpec(&profile.Custom.TLSProfileSpec)
 	}
 	spec, ok := configv1.TLSProfiles[profile.Type]
 	if !ok || spec == nil {
 		spec = configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
 	}
-	return string(spec.MinTLSVersion), crypto.OpenSSLToIANACipherSuites(spec.Ciphers)
+	return tlsSettingsFromSpec(spec)
+}
+
+func tlsSettingsFromSpec(spec *configv1.TLSProfileSpec) TLSSettings {
+	return TLSSettings{
+		MinTLSVersion: string(spec.MinTLSVersion),
+		CipherSuites:  crypto.OpenSSLToIANACipherSuites(spec.Ciphers),
+	}
 }
 
 // OperatorConfigYAML produces a minimal GenericOperatorConfig YAML with only
 // the TLS fields set, omitting all zero-value fields that the typed struct would emit.
-func OperatorConfigYAML(minTLSVersion string, cipherSuites []string) (string, error) {
+// Only fields of configv1.ServingInfo are written, so the ConfigMap hash changes only
+// when the operands see a different config.
+func OperatorConfigYAML(settings TLSSettings) (string, error) {
 	cfg := map[string]interface{}{
 		"apiVersion": operatorv1alpha1.SchemeGroupVersion.String(),
 		"kind":       "GenericOperatorConfig",
 		"servingInfo": map[string]interface{}{
-			"minTLSVersion": minTLSVersion,
-			"cipherSuites":  cipherSuites,
+			"minTLSVersion": settings.MinTLSVersion,
+			"cipherSuites":  settings.CipherSuites,
 		},
 	}
 	data, err := sigsyaml.Marshal(cfg)
@@ -47,3 +77,58 @@ func OperatorConfigYAML(minTLSVersion string, cipherSuites []string) (string, er
 	}
 	return string(data), nil
 }
+
+// OperatorConfigHash returns hash of data of the operator config ConfigMap.
+func OperatorConfigHash(cm *corev1.ConfigMap) (string, error) {
+	hash, err := resourcehash.GetConfigMapHash(cm)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute hash of ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	return hash, nil
+}
+
+// SetOperatorConfigHash annotates the Deployment and its pod template with the operator config hash.
+func SetOperatorConfigHash(deployment *appsv1.Deployment, hash string) {
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Annotations[OperatorConfigHashAnnotation] = hash
+	deployment.Spec.Template.Annotations[OperatorConfigHashAnnotation] = hash
+}
+
+// OperatorConfigRolledOut returns true when all pods of the Deployment were created from
+// a pod template with the given operator config hash.
+func OperatorConfigRolledOut(deployment *appsv1.Deployment, hash string) bool {
+	if deployment.Spec.Template.Annotations[OperatorConfigHashAnnotation] != hash {
+		return false
+	}
+	if deployment.Generation != deployment.Status.ObservedGeneration {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas >= replicas && deployment.Status.Replicas == deployment.Status.UpdatedReplicas
+}
+
+// TLSProfileProgressingCondition returns <prefix>TLSProfileProgressing condition, which is
+// true while the Deployment still runs pods with a previous operator config.
+func TLSProfileProgressingCondition(prefix string, deployment *appsv1.Deployment, hash string) operatorv1.OperatorCondition {
+	if OperatorConfigRolledOut(deployment, hash) {
+		return operatorv1.OperatorCondition{
+			Type:   prefix + TLSProfileProgressingConditionSuffix,
+			Status: operatorv1.ConditionFalse,
+			Reason: "AsExpected",
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    prefix + TLSProfileProgressingConditionSuffix,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "RollingOut",
+		Message: fmt.Sprintf("Deployment %s/%s is still running pods with the previous TLS security profile", deployment.Namespace, deployment.Name),
+	}
+}
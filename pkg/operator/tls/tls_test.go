@@ -0,0 +1,120 @@
+package tls
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func operatorConfigMap(t *testing.T, profile *configv1.TLSSecurityProfile) *corev1.ConfigMap {
+	yaml, err := OperatorConfigYAML(TLSSettingsFromProfile(profile))
+	if err != nil {
+		t.Fatalf("OperatorConfigYAML failed: %v", err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-config", Namespace: "test"},
+		Data:       map[string]string{"config.yaml": yaml},
+	}
+}
+
+func TestOperatorConfigHash(t *testing.T) {
+	intermediate := operatorConfigMap(t, nil)
+	modern := operatorConfigMap(t, &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType})
+
+	hash1, err := OperatorConfigHash(intermediate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hash2, err := OperatorConfigHash(intermediate.DeepCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Errorf("expected the same hash for the same config, got %q and %q", hash1, hash2)
+	}
+
+	hash3, err := OperatorConfigHash(modern)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash1 == hash3 {
+		t.Errorf("expected different hash for a different TLS profile, got %q", hash1)
+	}
+}
+
+func TestTLSProfileProgressingCondition(t *testing.T) {
+	rolledOut := func(hash string) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "test", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2},
+		}
+		SetOperatorConfigHash(d, hash)
+		return d
+	}
+
+	tests := []struct {
+		name           string
+		deployment     *appsv1.Deployment
+		expectedStatus operatorv1.ConditionStatus
+	}{
+		{
+			name:           "rolled out",
+			deployment:     rolledOut("new"),
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:           "old hash",
+			deployment:     rolledOut("old"),
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+		{
+			name: "generation not observed",
+			deployment: func() *appsv1.Deployment {
+				d := rolledOut("new")
+				d.Generation = 3
+				return d
+			}(),
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+		{
+			name: "old pods still running",
+			deployment: func() *appsv1.Deployment {
+				d := rolledOut("new")
+				d.Status.Replicas = 3
+				return d
+			}(),
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+		{
+			name: "new pods not created yet",
+			deployment: func() *appsv1.Deployment {
+				d := rolledOut("new")
+				d.Status.UpdatedReplicas = 1
+				d.Status.Replicas = 1
+				return d
+			}(),
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := TLSProfileProgressingCondition("Test", tt.deployment, "new")
+			if cond.Type != "TestTLSProfileProgressing" {
+				t.Errorf("unexpected condition type %q", cond.Type)
+			}
+			if cond.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s: %s", tt.expectedStatus, cond.Status, cond.Message)
+			}
+			if cond.Status == operatorv1.ConditionTrue && cond.Message != "Deployment test/operator is still running pods with the previous TLS security profile" {
+				t.Errorf("unexpected message %q", cond.Message)
+			}
+		})
+	}
+}
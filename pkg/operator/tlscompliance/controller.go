@@ -0,0 +1,445 @@
+package tlscompliance
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	csotls "github.com/openshift/cluster-storage-operator/pkg/operator/tls"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// complianceConditionType is informational, it is not unioned into the ClusterOperator
+	// conditions. Operands restart during every TLS profile rollout and CSO must not become
+	// Degraded because of that. Alerts use the metrics.
+	complianceConditionType = "TLSProfileCompliant"
+
+	// probeFailureThreshold is the number of consecutive failed probes after which an
+	// endpoint is reported as failed instead of being skipped.
+	probeFailureThreshold = 3
+
+	// CSO's own metrics Service is not in assets, it's deployed by CVO.
+	operatorMetricsServiceName = "cluster-storage-operator-metrics"
+
+	vSphereProblemDetectorServiceAsset = "vsphere_problem_detector/10_service.yaml"
+)
+
+var (
+	tlsProfileMismatch = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "openshift_cluster_storage_tls_profile_mismatch",
+			Help:           "Indicates whether a metrics endpoint deployed by the cluster storage operator serves TLS settings different from the cluster TLS security profile. 0 means the settings match, 1 means they differ.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "service"},
+	)
+	tlsProfileProbeFailed = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "openshift_cluster_storage_tls_profile_probe_failed",
+			Help:           "Indicates whether the TLS settings of a metrics endpoint deployed by the cluster storage operator could not be checked. 1 means the last 3 or more probes failed, 0 means the endpoint was probed.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "service"},
+	)
+	registerMetrics sync.Once
+)
+
+// Controller connects to every metrics Service deployed by CSO and checks that it is served
+// with TLS settings of the cluster TLS security profile, i.e. APIServer/cluster in standalone
+// clusters and the HostedControlPlane in HyperShift. It produces informational
+// TLSProfileCompliant condition and openshift_cluster_storage_tls_profile_mismatch and
+// openshift_cluster_storage_tls_profile_probe_failed metrics.
+// Endpoints whose Service does not exist, e.g. CSI drivers of other platforms, are skipped.
+// Endpoints that are not reachable are skipped until probeFailureThreshold consecutive
+// probes fail. The condition is not updated while any operand rolls out a new TLS profile.
+type Controller struct {
+	operatorClient   v1helpers.OperatorClient
+	expectedSettings func() (csotls.TLSSettings, error)
+	serviceListers   map[string]corelisters.ServiceNamespaceLister
+	endpoints        []types.NamespacedName
+	probe            probeFunc
+	probeFailures    map[types.NamespacedName]int
+	eventRecorder    events.Recorder
+}
+
+func NewStandaloneController(
+	clients *csoclients.Clients,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder,
+) (factory.Controller, error) {
+	c, informers, err := newStandaloneController(clients, csiDriverConfigs, eventRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return c.toController(informers, resyncInterval), nil
+}
+
+func newStandaloneController(
+	clients *csoclients.Clients,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	eventRecorder events.Recorder,
+) (*Controller, []factory.Informer, error) {
+	endpoints, err := standaloneEndpoints(csiDriverConfigs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apiServerLister := clients.ConfigInformers.Config().V1().APIServers().Lister()
+	c := newController(clients.OperatorClient, endpoints, eventRecorder)
+	c.expectedSettings = func() (csotls.TLSSettings, error) {
+		apiServer, err := apiServerLister.Get("cluster")
+		if err != nil {
+			return csotls.TLSSettings{}, fmt.Errorf("failed to get APIServer cluster: %w", err)
+		}
+		return csotls.TLSSettingsFromProfile(apiServer.Spec.TLSSecurityProfile), nil
+	}
+
+	informers := []factory.Informer{
+		clients.OperatorClient.Informer(),
+		clients.ConfigInformers.Config().V1().APIServers().Informer(),
+	}
+	for _, namespace := range []string{csoclients.OperatorNamespace, csoclients.CSIOperatorNamespace} {
+		informers = append(informers, c.addServiceInformer(clients, namespace))
+	}
+	return c, informers, nil
+}
+
+// NewHyperShiftController checks metrics Services of CSI driver operators in the control
+// plane namespace of the mgmt cluster and reports the condition to the guest cluster.
+func NewHyperShiftController(
+	guestClients *csoclients.Clients,
+	mgmtClients *csoclients.Clients,
+	controlPlaneNamespace string,
+	hostedControlPlaneName string,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	resyncInterval time.Duration,
+	eventRecorder events.Recorder,
+) (factory.Controller, error) {
+	c, informers, err := newHyperShiftController(guestClients, mgmtClients, controlPlaneNamespace, hostedControlPlaneName, csiDriverConfigs, eventRecorder)
+	if err != nil {
+		return nil, err
+	}
+	return c.toController(informers, resyncInterval), nil
+}
+
+func newHyperShiftController(
+	guestClients *csoclients.Clients,
+	mgmtClients *csoclients.Clients,
+	controlPlaneNamespace string,
+	hostedControlPlaneName string,
+	csiDriverConfigs []csioperatorclient.CSIOperatorConfig,
+	eventRecorder events.Recorder,
+) (*Controller, []factory.Informer, error) {
+	endpoints, err := hyperShiftEndpoints(csiDriverConfigs, controlPlaneNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostedControlPlaneInformer := mgmtClients.DynamicInformer.ForResource(csidriveroperator.HostedControlPlaneGVR)
+	c := newController(guestClients.OperatorClient, endpoints, eventRecorder)
+	c.expectedSettings = func() (csotls.TLSSettings, error) {
+		return csidriveroperator.HostedControlPlaneTLSSettings(hostedControlPlaneInformer.Lister(), controlPlaneNamespace, hostedControlPlaneName)
+	}
+
+	informers := []factory.Informer{
+		guestClients.OperatorClient.Informer(),
+		hostedControlPlaneInformer.Informer(),
+		c.addServiceInformer(mgmtClients, controlPlaneNamespace),
+	}
+	return c, informers, nil
+}
+
+func newController(operatorClient v1helpers.OperatorClient, endpoints []types.NamespacedName, eventRecorder events.Recorder) *Controller {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(tlsProfileMismatch)
+		legacyregistry.MustRegister(tlsProfileProbeFailed)
+	})
+	return &Controller{
+		operatorClient: operatorClient,
+		serviceListers: map[string]corelisters.ServiceNamespaceLister{},
+		endpoints:      endpoints,
+		probe:          probeTLS,
+		probeFailures:  map[types.NamespacedName]int{},
+		eventRecorder:  eventRecorder,
+	}
+}
+
+func (c *Controller) addServiceInformer(clients *csoclients.Clients, namespace string) factory.Informer {
+	services := clients.KubeInformers.InformersFor(namespace).Core().V1().Services()
+	c.serviceListers[namespace] = services.Lister().Services(namespace)
+	return services.Informer()
+}
+
+func (c *Controller) toController(informers []factory.Informer, resyncInterval time.Duration) factory.Controller {
+	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(c.operatorClient).WithInformers(
+		informers...,
+	).ResyncEvery(resyncInterval).ToController("TLSProfileComplianceController", c.eventRecorder)
+}
+
+func (c *Controller) sync(ctx context.Context, _ factory.SyncContext) error {
+	klog.V(4).Info("TLSProfileComplianceController sync started")
+	defer klog.V(4).Info("TLSProfileComplianceController sync finished")
+
+	opSpec, opStatus, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	expected, err := c.expectedSettings()
+	if err != nil {
+		return err
+	}
+
+	checks, err := c.checkEndpoints(ctx, expected)
+	if err != nil {
+		return err
+	}
+
+	// The metrics are replaced only when all endpoints were checked.
+	var mismatches, failures []string
+	tlsProfileMismatch.Reset()
+	tlsProfileProbeFailed.Reset()
+	for _, check := range checks {
+		e := check.endpoint
+		switch check.result {
+		case endpointSkipped:
+			continue
+		case endpointProbeFailed:
+			failures = append(failures, check.message)
+			tlsProfileProbeFailed.WithLabelValues(e.Namespace, e.Name).Set(1)
+			continue
+		case endpointMismatch:
+			mismatches = append(mismatches, check.message)
+			tlsProfileMismatch.WithLabelValues(e.Namespace, e.Name).Set(1)
+		default:
+			tlsProfileMismatch.WithLabelValues(e.Namespace, e.Name).Set(0)
+		}
+		tlsProfileProbeFailed.WithLabelValues(e.Namespace, e.Name).Set(0)
+	}
+
+	if progressing := tlsProfileProgressing(opStatus.Conditions); progressing != "" {
+		klog.V(4).Infof("%s is rolling out the TLS profile, not updating %s condition", progressing, complianceConditionType)
+		return nil
+	}
+
+	compliantCnd := operatorapi.OperatorCondition{
+		Type:   complianceConditionType,
+		Status: operatorapi.ConditionTrue,
+		Reason: "AsExpected",
+	}
+	if len(mismatches) > 0 || len(failures) > 0 {
+		sort.Strings(mismatches)
+		sort.Strings(failures)
+		compliantCnd.Status = operatorapi.ConditionFalse
+		compliantCnd.Reason = "TLSProfileProbeFailed"
+		if len(mismatches) > 0 {
+			compliantCnd.Reason = "TLSProfileMismatch"
+		}
+		compliantCnd.Message = strings.Join(append(mismatches, failures...), "\n")
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(compliantCnd))
+	return err
+}
+
+// tlsProfileProgressing returns the type of the first TLSProfileProgressing condition that
+// is True, empty when no operand is rolling out a new TLS profile.
+func tlsProfileProgressing(conditions []operatorapi.OperatorCondition) string {
+	for _, cnd := range conditions {
+		if strings.HasSuffix(cnd.Type, csotls.TLSProfileProgressingConditionSuffix) && cnd.Status == operatorapi.ConditionTrue {
+			return cnd.Type
+		}
+	}
+	return ""
+}
+
+type endpointResult int
+
+const (
+	endpointSkipped endpointResult = iota
+	endpointCompliant
+	endpointMismatch
+	endpointProbeFailed
+)
+
+// endpointCheck is the result of the TLS profile check of one endpoint.
+type endpointCheck struct {
+	endpoint types.NamespacedName
+	// address is empty when the endpoint is not probed.
+	address  string
+	mismatch string
+	probeErr error
+	result   endpointResult
+	message  string
+}
+
+// checkEndpoints probes the first port of the Service of each endpoint. The endpoints are
+// probed in parallel, so a sync takes as long as the slowest probe and not their sum.
+func (c *Controller) checkEndpoints(ctx context.Context, expected csotls.TLSSettings) ([]endpointCheck, error) {
+	checks := make([]endpointCheck, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		address, err := c.endpointAddress(e)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, endpointCheck{endpoint: e, address: address})
+	}
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		if checks[i].address == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(check *endpointCheck) {
+			defer wg.Done()
+			check.mismatch, check.probeErr = c.probe(ctx, check.address, expected)
+		}(&checks[i])
+	}
+	wg.Wait()
+
+	for i := range checks {
+		checks[i].result, checks[i].message = c.evaluateCheck(&checks[i])
+	}
+	return checks, nil
+}
+
+// endpointAddress returns the address of the first port of the endpoint Service, empty when
+// the Service does not exist or has no ports.
+func (c *Controller) endpointAddress(e types.NamespacedName) (string, error) {
+	serviceLister, ok := c.serviceListers[e.Namespace]
+	if !ok {
+		return "", fmt.Errorf("no informer for Services in namespace %s", e.Namespace)
+	}
+	svc, err := serviceLister.Get(e.Name)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(svc.Spec.Ports) == 0 {
+		klog.V(2).Infof("Service %s has no ports, skipping TLS profile check", e)
+		return "", nil
+	}
+	return net.JoinHostPort(fmt.Sprintf("%s.%s.svc", e.Name, e.Namespace), strconv.Itoa(int(svc.Spec.Ports[0].Port))), nil
+}
+
+// evaluateCheck returns endpointSkipped when the endpoint was not probed or the probe could
+// not connect to it less than probeFailureThreshold times in a row. Otherwise it returns a
+// description of the TLS settings mismatch or of the probe failure.
+func (c *Controller) evaluateCheck(check *endpointCheck) (endpointResult, string) {
+	e := check.endpoint
+	if check.address == "" {
+		delete(c.probeFailures, e)
+		return endpointSkipped, ""
+	}
+	if check.probeErr != nil {
+		c.probeFailures[e]++
+		if c.probeFailures[e] < probeFailureThreshold {
+			// The operand may be still starting, the next resync will check it again.
+			klog.V(2).Infof("Skipping TLS profile check of Service %s: %v", e, check.probeErr)
+			return endpointSkipped, ""
+		}
+		message := fmt.Sprintf("Service %s: failed to check TLS settings %d times: %v", e, c.probeFailures[e], check.probeErr)
+		klog.V(2).Info(message)
+		return endpointProbeFailed, message
+	}
+	delete(c.probeFailures, e)
+	if check.mismatch == "" {
+		return endpointCompliant, ""
+	}
+	mismatch := fmt.Sprintf("Service %s: %s", e, check.mismatch)
+	klog.V(2).Info(mismatch)
+	return endpointMismatch, mismatch
+}
+
+// standaloneEndpoints returns metrics Services of CSO, the vSphere problem detector and
+// all CSI driver operators in a standalone cluster.
+func standaloneEndpoints(csiDriverConfigs []csioperatorclient.CSIOperatorConfig) ([]types.NamespacedName, error) {
+	endpoints := []types.NamespacedName{
+		{Namespace: csoclients.OperatorNamespace, Name: operatorMetricsServiceName},
+	}
+
+	detector, err := serviceFromAssets([]string{vSphereProblemDetectorServiceAsset})
+	if err != nil {
+		return nil, err
+	}
+	endpoints = append(endpoints, *detector)
+
+	for _, cfg := range csiDriverConfigs {
+		e, err := serviceFromAssets(cfg.StaticAssets)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.ConditionPrefix, err)
+		}
+		if e == nil {
+			klog.V(2).Infof("CSI driver operator %s has no metrics Service", cfg.ConditionPrefix)
+			continue
+		}
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, nil
+}
+
+// hyperShiftEndpoints returns metrics Services of all CSI driver operators in the control
+// plane namespace. CSO and the vSphere problem detector do not run metrics Services there.
+func hyperShiftEndpoints(csiDriverConfigs []csioperatorclient.CSIOperatorConfig, controlPlaneNamespace string) ([]types.NamespacedName, error) {
+	var endpoints []types.NamespacedName
+	for _, cfg := range csiDriverConfigs {
+		e, err := serviceFromAssets(cfg.MgmtStaticAssets)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.ConditionPrefix, err)
+		}
+		if e == nil {
+			klog.V(2).Infof("CSI driver operator %s has no metrics Service", cfg.ConditionPrefix)
+			continue
+		}
+		// The mgmt assets use ${CONTROLPLANE_NAMESPACE} placeholder.
+		e.Namespace = controlPlaneNamespace
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, nil
+}
+
+// serviceFromAssets returns the first Service among the assets, nil when there is none.
+func serviceFromAssets(assetNames []string) (*types.NamespacedName, error) {
+	for _, name := range assetNames {
+		assetBytes, err := assets.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read asset %s: %w", name, err)
+		}
+		obj := &metav1.PartialObjectMetadata{}
+		if err := sigsyaml.Unmarshal(assetBytes, obj); err != nil {
+			return nil, fmt.Errorf("failed to decode asset %s: %w", name, err)
+		}
+		if obj.Kind == "Service" {
+			return &types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name}, nil
+		}
+	}
+	return nil, nil
+}
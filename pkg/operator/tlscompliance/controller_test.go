@@ -0,0 +1,343 @@
+package tlscompliance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"github.com/openshift/cluster-storage-operator/pkg/operator/csidriveroperator/csioperatorclient"
+	csotls "github.com/openshift/cluster-storage-operator/pkg/operator/tls"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const (
+	awsServiceName    = "aws-ebs-csi-driver-operator-metrics"
+	awsAddress        = "aws-ebs-csi-driver-operator-metrics.openshift-cluster-csi-drivers.svc:443"
+	detectorService   = "vsphere-problem-detector-metrics"
+	detectorAddress   = "vsphere-problem-detector-metrics.openshift-cluster-storage-operator.svc:8444"
+	awsMismatchReason = "accepts VersionTLS12, expected at least VersionTLS13"
+)
+
+func modernAPIServer() *configv1.APIServer {
+	return &configv1.APIServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.APIServerSpec{
+			TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+		},
+	}
+}
+
+func service(namespace, name string, port int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "https", Port: port}}},
+	}
+}
+
+// fakeProbe returns mismatches and errors by the probed address. Addresses not in
+// the maps are compliant.
+type fakeProbe struct {
+	// lock protects expected, the controller probes endpoints in parallel.
+	lock       sync.Mutex
+	mismatches map[string]string
+	errors     map[string]error
+	expected   []csotls.TLSSettings
+}
+
+func (p *fakeProbe) probe(_ context.Context, address string, expected csotls.TLSSettings) (string, error) {
+	p.lock.Lock()
+	p.expected = append(p.expected, expected)
+	p.lock.Unlock()
+	if err := p.errors[address]; err != nil {
+		return "", err
+	}
+	return p.mismatches[address], nil
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		probe           *fakeProbe
+		conditions      []opv1.OperatorCondition
+		syncs           int
+		expectedStatus  opv1.ConditionStatus
+		expectedMessage string
+		expectedMetrics map[types.NamespacedName]float64
+		expectedFailed  map[types.NamespacedName]float64
+	}{
+		{
+			name:           "no metrics Services",
+			probe:          &fakeProbe{},
+			expectedStatus: opv1.ConditionTrue,
+		},
+		{
+			name: "compliant endpoints",
+			objects: []runtime.Object{
+				service(csoclients.OperatorNamespace, operatorMetricsServiceName, 8443),
+				service(csoclients.CSIOperatorNamespace, awsServiceName, 443),
+			},
+			probe:          &fakeProbe{},
+			expectedStatus: opv1.ConditionTrue,
+			expectedMetrics: map[types.NamespacedName]float64{
+				{Namespace: csoclients.OperatorNamespace, Name: operatorMetricsServiceName}: 0,
+				{Namespace: csoclients.CSIOperatorNamespace, Name: awsServiceName}:          0,
+			},
+		},
+		{
+			name: "endpoint served with the previous profile",
+			objects: []runtime.Object{
+				service(csoclients.OperatorNamespace, operatorMetricsServiceName, 8443),
+				service(csoclients.CSIOperatorNamespace, awsServiceName, 443),
+			},
+			probe: &fakeProbe{
+				mismatches: map[string]string{awsAddress: awsMismatchReason},
+			},
+			expectedStatus:  opv1.ConditionFalse,
+			expectedMessage: "Service openshift-cluster-csi-drivers/aws-ebs-csi-driver-operator-metrics: " + awsMismatchReason,
+			expectedMetrics: map[types.NamespacedName]float64{
+				{Namespace: csoclients.OperatorNamespace, Name: operatorMetricsServiceName}: 0,
+				{Namespace: csoclients.CSIOperatorNamespace, Name: awsServiceName}:          1,
+			},
+		},
+		{
+			name: "unreachable endpoint is skipped",
+			objects: []runtime.Object{
+				service(csoclients.OperatorNamespace, detectorService, 8444),
+				service(csoclients.CSIOperatorNamespace, awsServiceName, 443),
+			},
+			probe: &fakeProbe{
+				mismatches: map[string]string{detectorAddress: "accepts cipher suite TLS_RSA_WITH_AES_128_CBC_SHA"},
+				errors:     map[string]error{awsAddress: errors.New("connection refused")},
+			},
+			expectedStatus:  opv1.ConditionFalse,
+			expectedMessage: "Service openshift-cluster-storage-operator/vsphere-problem-detector-metrics: accepts cipher suite TLS_RSA_WITH_AES_128_CBC_SHA",
+			expectedMetrics: map[types.NamespacedName]float64{
+				{Namespace: csoclients.OperatorNamespace, Name: detectorService}: 1,
+			},
+		},
+		{
+			name: "endpoint unreachable in consecutive syncs is reported",
+			objects: []runtime.Object{
+				service(csoclients.OperatorNamespace, operatorMetricsServiceName, 8443),
+				service(csoclients.CSIOperatorNamespace, awsServiceName, 443),
+			},
+			probe: &fakeProbe{
+				errors: map[string]error{awsAddress: errors.New("connection refused")},
+			},
+			syncs:           probeFailureThreshold,
+			expectedStatus:  opv1.ConditionFalse,
+			expectedMessage: "Service openshift-cluster-csi-drivers/aws-ebs-csi-driver-operator-metrics: failed to check TLS settings 3 times: connection refused",
+			expectedMetrics: map[types.NamespacedName]float64{
+				{Namespace: csoclients.OperatorNamespace, Name: operatorMetricsServiceName}: 0,
+			},
+			expectedFailed: map[types.NamespacedName]float64{
+				{Namespace: csoclients.OperatorNamespace, Name: operatorMetricsServiceName}: 0,
+				{Namespace: csoclients.CSIOperatorNamespace, Name: awsServiceName}:          1,
+			},
+		},
+		{
+			name: "condition is not updated during TLS profile rollout",
+			objects: []runtime.Object{
+				service(csoclients.CSIOperatorNamespace, awsServiceName, 443),
+			},
+			probe: &fakeProbe{
+				mismatches: map[string]string{awsAddress: awsMismatchReason},
+			},
+			conditions: []opv1.OperatorCondition{
+				{Type: complianceConditionType, Status: opv1.ConditionTrue, Reason: "AsExpected"},
+				{Type: "AWSEBS" + csotls.TLSProfileProgressingConditionSuffix, Status: opv1.ConditionTrue},
+			},
+			expectedStatus: opv1.ConditionTrue,
+			expectedMetrics: map[types.NamespacedName]float64{
+				{Namespace: csoclients.CSIOperatorNamespace, Name: awsServiceName}: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := csoclients.GetCR()
+			cr.Status.Conditions = tt.conditions
+			clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+				CoreObjects:     tt.objects,
+				OperatorObjects: []runtime.Object{cr},
+				ConfigObjects:   []runtime.Object{modernAPIServer()},
+			})
+			recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+			ctrl, informers, err := newStandaloneController(clients, []csioperatorclient.CSIOperatorConfig{csioperatorclient.GetAWSEBSCSIOperatorConfig(false)}, recorder)
+			if err != nil {
+				t.Fatalf("failed to create controller: %v", err)
+			}
+			ctrl.probe = tt.probe.probe
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			csoclients.StartInformers(clients, stopCh)
+			var synced []cache.InformerSynced
+			for _, informer := range informers {
+				synced = append(synced, informer.HasSynced)
+			}
+			if !cache.WaitForCacheSync(stopCh, synced...) {
+				t.Fatal("timed out waiting for informer cache sync")
+			}
+
+			for i := 0; i < max(tt.syncs, 1); i++ {
+				if err := ctrl.sync(context.TODO(), nil); err != nil {
+					t.Fatalf("sync() returned unexpected error: %v", err)
+				}
+			}
+
+			_, status, _, err := clients.OperatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("failed to get Storage: %v", err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, complianceConditionType)
+			if cond == nil {
+				t.Fatal("TLSProfileCompliant condition not found")
+			}
+			if cond.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, cond.Status)
+			}
+			if cond.Status == opv1.ConditionTrue && cond.Reason != "AsExpected" {
+				t.Errorf("expected reason AsExpected, got %s", cond.Reason)
+			}
+			if cond.Message != tt.expectedMessage {
+				t.Errorf("unexpected message:\nexpected: %s\ngot:      %s", tt.expectedMessage, cond.Message)
+			}
+
+			for svc, expected := range tt.expectedMetrics {
+				value, err := testutil.GetGaugeMetricValue(tlsProfileMismatch.WithLabelValues(svc.Namespace, svc.Name))
+				if err != nil {
+					t.Fatalf("failed to get metric value: %v", err)
+				}
+				if value != expected {
+					t.Errorf("expected metric value %v for Service %s, got %v", expected, svc, value)
+				}
+			}
+			for svc, expected := range tt.expectedFailed {
+				value, err := testutil.GetGaugeMetricValue(tlsProfileProbeFailed.WithLabelValues(svc.Namespace, svc.Name))
+				if err != nil {
+					t.Fatalf("failed to get metric value: %v", err)
+				}
+				if value != expected {
+					t.Errorf("expected probe failed metric value %v for Service %s, got %v", expected, svc, value)
+				}
+			}
+
+			for _, expected := range tt.probe.expected {
+				if expected.MinTLSVersion != string(configv1.VersionTLS13) {
+					t.Errorf("expected endpoints to be probed with the Modern profile, got %+v", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncKeepsMetricsOnError(t *testing.T) {
+	clients := csoclients.NewFakeClients(&csoclients.FakeTestObjects{
+		CoreObjects:     []runtime.Object{service(csoclients.CSIOperatorNamespace, awsServiceName, 443)},
+		OperatorObjects: []runtime.Object{csoclients.GetCR()},
+		ConfigObjects:   []runtime.Object{modernAPIServer()},
+	})
+	recorder := events.NewInMemoryRecorder("operator", clocktesting.NewFakePassiveClock(time.Now()))
+	ctrl, informers, err := newStandaloneController(clients, []csioperatorclient.CSIOperatorConfig{csioperatorclient.GetAWSEBSCSIOperatorConfig(false)}, recorder)
+	if err != nil {
+		t.Fatalf("failed to create controller: %v", err)
+	}
+	ctrl.probe = (&fakeProbe{mismatches: map[string]string{awsAddress: awsMismatchReason}}).probe
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	csoclients.StartInformers(clients, stopCh)
+	var synced []cache.InformerSynced
+	for _, informer := range informers {
+		synced = append(synced, informer.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
+		t.Fatal("timed out waiting for informer cache sync")
+	}
+
+	if err := ctrl.sync(context.TODO(), nil); err != nil {
+		t.Fatalf("sync() returned unexpected error: %v", err)
+	}
+	// A sync that fails to check the endpoints must not clear the metrics of the previous one.
+	delete(ctrl.serviceListers, csoclients.OperatorNamespace)
+	if err := ctrl.sync(context.TODO(), nil); err == nil {
+		t.Fatal("expected sync() to fail without a Service lister")
+	}
+	value, err := testutil.GetGaugeMetricValue(tlsProfileMismatch.WithLabelValues(csoclients.CSIOperatorNamespace, awsServiceName))
+	if err != nil {
+		t.Fatalf("failed to get metric value: %v", err)
+	}
+	if value != 1 {
+		t.Errorf("expected metric value 1 for Service %s, got %v", awsServiceName, value)
+	}
+}
+
+func TestStandaloneEndpoints(t *testing.T) {
+	configs := []csioperatorclient.CSIOperatorConfig{
+		csioperatorclient.GetAzureDiskCSIOperatorConfig(false),
+		csioperatorclient.GetAzureFileCSIOperatorConfig(false),
+		csioperatorclient.GetAWSEBSCSIOperatorConfig(false),
+		csioperatorclient.GetGCPPDCSIOperatorConfig(false),
+		csioperatorclient.GetIBMVPCBlockCSIOperatorConfig(false),
+		csioperatorclient.GetOpenStackManilaOperatorConfig(false),
+		csioperatorclient.GetOpenStackCinderCSIOperatorConfig(false),
+		csioperatorclient.GetPowerVSBlockCSIOperatorConfig(false),
+		csioperatorclient.GetVMwareVSphereCSIOperatorConfig(false),
+	}
+	endpoints, err := standaloneEndpoints(configs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// CSO, the vSphere problem detector and one endpoint per CSI driver operator
+	if len(endpoints) != len(configs)+2 {
+		t.Fatalf("expected %d endpoints, got %d: %+v", len(configs)+2, len(endpoints), endpoints)
+	}
+	for _, e := range endpoints {
+		if e.Name == "" || e.Namespace == "" {
+			t.Errorf("incomplete endpoint %+v", e)
+		}
+	}
+}
+
+func TestHyperShiftEndpoints(t *testing.T) {
+	const controlPlaneNamespace = "clusters-test"
+	configs := []csioperatorclient.CSIOperatorConfig{
+		csioperatorclient.GetAzureDiskCSIOperatorConfig(true),
+		csioperatorclient.GetAzureFileCSIOperatorConfig(true),
+		csioperatorclient.GetAWSEBSCSIOperatorConfig(true),
+		csioperatorclient.GetGCPPDCSIOperatorConfig(true),
+		csioperatorclient.GetIBMVPCBlockCSIOperatorConfig(true),
+		csioperatorclient.GetOpenStackManilaOperatorConfig(true),
+		csioperatorclient.GetOpenStackCinderCSIOperatorConfig(true),
+		csioperatorclient.GetPowerVSBlockCSIOperatorConfig(true),
+		csioperatorclient.GetVMwareVSphereCSIOperatorConfig(true),
+	}
+	endpoints, err := hyperShiftEndpoints(configs, controlPlaneNamespace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != len(configs) {
+		t.Fatalf("expected %d endpoints, got %d: %+v", len(configs), len(endpoints), endpoints)
+	}
+	for _, e := range endpoints {
+		if e.Name == "" || e.Namespace != controlPlaneNamespace {
+			t.Errorf("unexpected endpoint %+v", e)
+		}
+	}
+}
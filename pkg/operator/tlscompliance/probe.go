@@ -0,0 +1,76 @@
+package tlscompliance
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	csotls "github.com/openshift/cluster-storage-operator/pkg/operator/tls"
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+const probeTimeout = 10 * time.Second
+
+// probeFunc checks TLS settings of a server. It returns a description of settings the server
+// accepts but the expected settings do not allow, empty when the server is compliant.
+type probeFunc func(ctx context.Context, address string, expected csotls.TLSSettings) (string, error)
+
+// probeTLS offers the server TLS handshakes that the expected settings do not allow: TLS
+// versions older than the minimal one and cipher suites outside of the profile. Each handshake
+// the server accepts is a mismatch. It returns an error when the server does not accept a plain
+// TLS handshake. Only the settings CSO passes to the operands are checked: TLS 1.3 cipher suites
+// are not configurable in Go servers and key exchange groups have no field in the operator config.
+func probeTLS(ctx context.Context, address string, expected csotls.TLSSettings) (string, error) {
+	minVersion, err := crypto.TLSVersion(expected.MinTLSVersion)
+	if err != nil {
+		return "", err
+	}
+	if _, err := handshake(ctx, address, &tls.Config{MinVersion: tls.VersionTLS10}); err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	var problems []string
+	if minVersion > tls.VersionTLS10 {
+		state, err := handshake(ctx, address, &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: minVersion - 1})
+		if err == nil {
+			problems = append(problems, fmt.Sprintf("accepts %s, expected at least %s", crypto.TLSVersionToNameOrDie(state.Version), expected.MinTLSVersion))
+		}
+	}
+	if suites := disallowedCipherSuites(expected.CipherSuites); minVersion <= tls.VersionTLS12 && len(suites) > 0 {
+		state, err := handshake(ctx, address, &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS12, CipherSuites: suites})
+		if err == nil {
+			problems = append(problems, fmt.Sprintf("accepts cipher suite %s", tls.CipherSuiteName(state.CipherSuite)))
+		}
+	}
+	return strings.Join(problems, ", "), nil
+}
+
+func handshake(ctx context.Context, address string, config *tls.Config) (tls.ConnectionState, error) {
+	// Only the negotiated parameters are checked and no data is exchanged,
+	// so the server certificate does not need to be verified.
+	config.InsecureSkipVerify = true
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: probeTimeout}, Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+// disallowedCipherSuites returns TLS 1.0 - 1.2 cipher suites known to Go, including the
+// insecure ones, that are not among the allowed IANA cipher suite names.
+func disallowedCipherSuites(allowed []string) []uint16 {
+	var suites []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if slices.Contains(allowed, suite.Name) || !slices.ContainsFunc(suite.SupportedVersions, func(v uint16) bool { return v <= tls.VersionTLS12 }) {
+			continue
+		}
+		suites = append(suites, suite.ID)
+	}
+	return suites
+}
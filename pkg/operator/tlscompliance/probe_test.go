@@ -0,0 +1,82 @@
+package tlscompliance
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	csotls "github.com/openshift/cluster-storage-operator/pkg/operator/tls"
+)
+
+func TestProbeTLS(t *testing.T) {
+	modern := csotls.TLSSettingsFromProfile(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType})
+	intermediate := csotls.TLSSettingsFromProfile(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileIntermediateType})
+
+	tests := []struct {
+		name             string
+		serverConfig     *tls.Config
+		expected         csotls.TLSSettings
+		expectedMismatch string
+	}{
+		{
+			name:         "modern server, modern profile",
+			serverConfig: &tls.Config{MinVersion: tls.VersionTLS13},
+			expected:     modern,
+		},
+		{
+			name:             "intermediate server, modern profile",
+			serverConfig:     &tls.Config{MinVersion: tls.VersionTLS12},
+			expected:         modern,
+			expectedMismatch: "accepts VersionTLS12, expected at least VersionTLS13",
+		},
+		{
+			name: "intermediate server, intermediate profile",
+			serverConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			},
+			expected: intermediate,
+		},
+		{
+			name: "server with a CBC cipher suite, intermediate profile",
+			serverConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA},
+			},
+			expected:         intermediate,
+			expectedMismatch: "accepts cipher suite TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			server.TLS = tt.serverConfig
+			server.StartTLS()
+			defer server.Close()
+
+			mismatch, err := probeTLS(context.TODO(), strings.TrimPrefix(server.URL, "https://"), tt.expected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mismatch != tt.expectedMismatch {
+				t.Errorf("expected mismatch %q, got %q", tt.expectedMismatch, mismatch)
+			}
+		})
+	}
+}
+
+func TestProbeTLSUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	address := strings.TrimPrefix(server.URL, "http://")
+	server.Close()
+
+	modern := csotls.TLSSettingsFromProfile(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType})
+	if _, err := probeTLS(context.TODO(), address, modern); err == nil {
+		t.Error("expected error for a closed endpoint")
+	}
+}
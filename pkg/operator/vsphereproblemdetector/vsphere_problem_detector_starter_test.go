@@ -0,0 +1,38 @@
+package vsphereproblemdetector
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-storage-operator/assets"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+)
+
+func TestDetectorAssets(t *testing.T) {
+	files := append([]string{
+		Detector.DeploymentAsset,
+		Detector.OperatorConfigAsset,
+		Detector.MetricsServiceAsset,
+		Detector.ServiceMonitorAsset,
+		Detector.PrometheusRuleAsset,
+	}, Detector.StaticAssets...)
+	for _, file := range files {
+		if _, err := assets.ReadFile(file); err != nil {
+			t.Errorf("failed to read asset %s: %v", file, err)
+		}
+	}
+
+	deploymentBytes, err := assets.ReadFile(Detector.DeploymentAsset)
+	if err != nil {
+		t.Fatalf("failed to read Deployment: %v", err)
+	}
+	if name := resourceread.ReadDeploymentV1OrDie(deploymentBytes).Name; name != Detector.DeploymentName {
+		t.Errorf("expected Deployment %s, got %s", Detector.DeploymentName, name)
+	}
+	operatorConfigBytes, err := assets.ReadFile(Detector.OperatorConfigAsset)
+	if err != nil {
+		t.Fatalf("failed to read operator config: %v", err)
+	}
+	if name := resourceread.ReadConfigMapV1OrDie(operatorConfigBytes).Name; name != Detector.OperatorConfigName {
+		t.Errorf("expected operator config %s, got %s", Detector.OperatorConfigName, name)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/openshift/cluster-storage-operator/pkg/csoclients"
+	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// OperatorConfigKey is the key of the YAML config in optional operator config ConfigMaps.
+const OperatorConfigKey = "config.yaml"
+
+// ParseOperatorConfigMap parses the optional ConfigMap with the given name in the CSO namespace
+// into out, which should be pre-filled with the defaults. Unknown fields are rejected.
+// It returns false and leaves out untouched when the ConfigMap does not exist.
+// See docs/operator-config-maps.md for the ConfigMaps read this way.
+func ParseOperatorConfigMap(lister listerv1.ConfigMapLister, name string, out interface{}) (bool, error) {
+	cm, err := lister.ConfigMaps(csoclients.OperatorNamespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		klog.V(4).Infof("Using default config, %s does not exist", name)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data, found := cm.Data[OperatorConfigKey]
+	if !found {
+		return false, fmt.Errorf("invalid format of ConfigMap %s: expected key %s", name, OperatorConfigKey)
+	}
+	if err := yaml.UnmarshalStrict([]byte(data), out); err != nil {
+		return false, fmt.Errorf("invalid format of ConfigMap %s: %s", name, err)
+	}
+	return true, nil
+}